@@ -12,10 +12,12 @@ import (
 	"mamonedz/internal/config"
 	"mamonedz/internal/database"
 	"mamonedz/internal/handlers"
+	"mamonedz/internal/mailer"
 	"mamonedz/internal/middleware"
 	"mamonedz/internal/models"
 	"mamonedz/internal/repository"
 	"mamonedz/internal/services"
+	"mamonedz/pkg/money"
 	"mamonedz/pkg/response"
 
 	"github.com/gin-gonic/gin"
@@ -27,27 +29,138 @@ func main() {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
+	if err := money.SetJSONFormat(cfg.MoneyJSONFormat); err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
 	db, err := database.Connect(cfg)
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 
 	// Auto migrate
-	if err := db.AutoMigrate(&models.User{}, &models.Expense{}); err != nil {
+	if err := db.AutoMigrate(
+		&models.User{},
+		&models.Expense{},
+		&models.RefreshToken{},
+		&models.RevokedToken{},
+		&models.UserToken{},
+		&models.RecoveryCode{},
+		&models.LoginAttempt{},
+		&models.PersonalAccessToken{},
+		&models.Session{},
+		&models.SigningKey{},
+		&models.ExternalIdentity{},
+		&models.OIDCState{},
+		&models.Category{},
+		&models.Tag{},
+		&models.Account{},
+		&models.Transfer{},
+		&models.ExchangeRate{},
+		&models.RecurringExpense{},
+		&models.Budget{},
+		&models.BudgetAlert{},
+		&models.SavingsGoal{},
+		&models.GoalContribution{},
+		&models.Ledger{},
+		&models.LedgerMember{},
+		&models.LedgerInvitation{},
+	); err != nil {
 		log.Fatalf("Failed to migrate database: %v", err)
 	}
 
 	// Setup repositories
 	userRepo := repository.NewUserRepository(db)
 	expenseRepo := repository.NewExpenseRepository(db)
+	refreshTokenRepo := repository.NewRefreshTokenRepository(db)
+	revokedTokenRepo := repository.NewRevokedTokenRepository(db)
+	userTokenRepo := repository.NewUserTokenRepository(db)
+	recoveryCodeRepo := repository.NewRecoveryCodeRepository(db)
+	patRepo := repository.NewPersonalAccessTokenRepository(db)
+	sessionRepo := repository.NewSessionRepository(db)
+	signingKeyRepo := repository.NewSigningKeyRepository(db)
+	identityRepo := repository.NewIdentityRepository(db)
+	adminRepo := repository.NewAdminRepository(db)
+	categoryRepo := repository.NewCategoryRepository(db)
+	tagRepo := repository.NewTagRepository(db)
+	accountRepo := repository.NewAccountRepository(db)
+	transferRepo := repository.NewTransferRepository(db)
+	exchangeRateRepo := repository.NewExchangeRateRepository(db)
+	recurringRepo := repository.NewRecurringExpenseRepository(db)
+	budgetRepo := repository.NewBudgetRepository(db)
+	goalRepo := repository.NewGoalRepository(db)
+	ledgerRepo := repository.NewLedgerRepository(db)
+
+	if err := categoryRepo.Backfill(); err != nil {
+		log.Fatalf("Failed to backfill categories: %v", err)
+	}
+	if moved, err := ledgerRepo.AssignPersonal(); err != nil {
+		log.Fatalf("Failed to assign personal ledgers: %v", err)
+	} else if moved > 0 {
+		log.Printf("Moved %d transaction(s) into personal ledgers", moved)
+	}
+
+	var loginAttempts repository.LoginAttemptStore
+	switch cfg.LoginAttemptStore {
+	case "postgres":
+		loginAttempts = repository.NewPostgresLoginAttemptStore(db)
+	default:
+		loginAttempts = repository.NewMemoryLoginAttemptStore()
+	}
+
+	mail, err := mailer.New(cfg)
+	if err != nil {
+		log.Fatalf("Failed to setup mailer: %v", err)
+	}
 
 	// Setup services
-	authService := services.NewAuthService(userRepo, cfg)
-	expenseService := services.NewExpenseService(expenseRepo)
+	patService := services.NewPersonalAccessTokenService(patRepo)
+	authService := services.NewAuthService(userRepo, refreshTokenRepo, revokedTokenRepo, userTokenRepo, recoveryCodeRepo, sessionRepo, signingKeyRepo, loginAttempts, patService, mail, cfg)
+	oidcService := services.NewOIDCService(identityRepo, userRepo, authService, cfg)
+	adminService := services.NewAdminService(userRepo, adminRepo, authService)
+	budgetService := services.NewBudgetService(budgetRepo, categoryRepo, userRepo, mail, cfg.AppURL)
+	expenseService := services.NewExpenseService(expenseRepo, categoryRepo, tagRepo, accountRepo, userRepo, ledgerRepo, budgetService)
+	categoryService := services.NewCategoryService(categoryRepo)
+	tagService := services.NewTagService(tagRepo)
+	accountService := services.NewAccountService(accountRepo)
+	transferService := services.NewTransferService(transferRepo, accountRepo, categoryRepo, ledgerRepo, budgetService)
+	currencyService := services.NewCurrencyService(exchangeRateRepo, userRepo, expenseRepo, ledgerRepo)
+	recurringService := services.NewRecurringService(recurringRepo, categoryRepo, accountRepo, userRepo, ledgerRepo, budgetService)
+	goalService := services.NewGoalService(goalRepo, transferRepo, accountRepo, userRepo)
+	ledgerService := services.NewLedgerService(ledgerRepo, userRepo, expenseRepo, mail, cfg.AppURL, cfg.LedgerInvitationTTL)
+
+	if promoted, err := adminService.PromoteAdmins(cfg.AdminEmails); err != nil {
+		log.Fatalf("Failed to promote admins: %v", err)
+	} else if promoted > 0 {
+		log.Printf("Promoted %d user(s) from ADMIN_EMAILS to admin", promoted)
+	}
+
+	if cfg.ExchangeRatesFile != "" {
+		result, err := currencyService.ImportRatesFile(cfg.ExchangeRatesFile)
+		if err != nil {
+			log.Fatalf("Failed to load exchange rates: %v", err)
+		}
+		log.Printf("Loaded %d exchange rate(s) from %s", result.Imported, cfg.ExchangeRatesFile)
+	}
+	if err := expenseRepo.ConvertMissing(); err != nil {
+		log.Fatalf("Failed to convert expenses: %v", err)
+	}
 
 	// Setup handlers
 	authHandler := handlers.NewAuthHandler(authService)
+	patHandler := handlers.NewPersonalAccessTokenHandler(patService)
+	oidcHandler := handlers.NewOIDCHandler(oidcService)
+	adminHandler := handlers.NewAdminHandler(adminService)
 	expenseHandler := handlers.NewExpenseHandler(expenseService)
+	categoryHandler := handlers.NewCategoryHandler(categoryService)
+	tagHandler := handlers.NewTagHandler(tagService)
+	accountHandler := handlers.NewAccountHandler(accountService)
+	transferHandler := handlers.NewTransferHandler(transferService)
+	currencyHandler := handlers.NewCurrencyHandler(currencyService)
+	recurringHandler := handlers.NewRecurringHandler(recurringService)
+	budgetHandler := handlers.NewBudgetHandler(budgetService)
+	goalHandler := handlers.NewGoalHandler(goalService)
+	ledgerHandler := handlers.NewLedgerHandler(ledgerService)
 
 	// Setup router
 	router := gin.New()
@@ -61,6 +174,9 @@ func main() {
 		response.Success(c, gin.H{"status": "healthy"})
 	})
 
+	// Public keys for verifying access tokens
+	router.GET("/.well-known/jwks.json", authHandler.JWKS)
+
 	// API routes
 	api := router.Group("/api/v1")
 	{
@@ -69,28 +185,194 @@ func main() {
 		{
 			auth.POST("/register", authHandler.Register)
 			auth.POST("/login", authHandler.Login)
+			auth.POST("/refresh", authHandler.Refresh)
+			auth.POST("/forgot-password", authHandler.ForgotPassword)
+			auth.POST("/reset-password", authHandler.ResetPassword)
+			auth.POST("/magic-link", authHandler.SendMagicLink)
+			auth.POST("/magic-link/verify", authHandler.LoginWithMagicLink)
+			auth.GET("/verify-email", authHandler.VerifyEmail)
+			auth.POST("/mfa/verify", authHandler.VerifyMFA)
+			auth.GET("/oidc/providers", oidcHandler.Providers)
+			auth.GET("/oidc/:provider/login", oidcHandler.Login)
+			auth.POST("/oidc/:provider/callback", oidcHandler.Callback)
 		}
 
 		// Protected routes
 		protected := api.Group("")
 		protected.Use(middleware.Auth(authService))
 		{
-			// Get current user
-			protected.GET("/auth/me", authHandler.Me)
+			// Account routes (not available to personal access tokens)
+			account := protected.Group("/auth")
+			account.Use(middleware.RequireSession())
+			{
+				account.GET("/me", authHandler.Me)
+				account.DELETE("/me", authHandler.DeleteAccount)
+				account.PUT("/password", authHandler.ChangePassword)
+				account.PUT("/email", authHandler.ChangeEmail)
+				account.PUT("/base-currency", currencyHandler.SetBaseCurrency)
+				account.POST("/logout", authHandler.Logout)
+				account.POST("/logout-all", authHandler.LogoutAll)
+				account.POST("/verify-email/resend", authHandler.ResendVerification)
+				account.POST("/mfa/setup", authHandler.SetupMFA)
+				account.POST("/mfa/enable", authHandler.EnableMFA)
+				account.POST("/mfa/disable", authHandler.DisableMFA)
+				account.GET("/sessions", authHandler.GetSessions)
+				account.DELETE("/sessions/:id", authHandler.RevokeSession)
+				account.GET("/tokens", patHandler.GetAll)
+				account.POST("/tokens", patHandler.Create)
+				account.DELETE("/tokens/:id", patHandler.Revoke)
+			}
 
 			// Expenses
+			expensesRead := middleware.RequireScope(models.ScopeExpensesRead)
+			expensesWrite := middleware.RequireScope(models.ScopeExpensesWrite)
+			statsRead := middleware.RequireScope(models.ScopeStatsRead)
+
 			expenses := protected.Group("/expenses")
+			expenses.Use(middleware.RequireVerifiedEmail(cfg.RequireVerifiedEmail))
+			{
+				expenses.GET("", expensesRead, expenseHandler.GetAll)
+				expenses.GET("/stats", statsRead, expenseHandler.GetStats)
+				expenses.GET("/:id", expensesRead, expenseHandler.GetByID)
+				expenses.POST("", expensesWrite, expenseHandler.Create)
+				expenses.PUT("/:id", expensesWrite, expenseHandler.Update)
+				expenses.DELETE("/:id", expensesWrite, expenseHandler.Delete)
+			}
+
+			// Categories
+			categories := protected.Group("/categories")
+			categories.Use(middleware.RequireVerifiedEmail(cfg.RequireVerifiedEmail))
 			{
-				expenses.GET("", expenseHandler.GetAll)
-				expenses.GET("/stats", expenseHandler.GetStats)
-				expenses.GET("/:id", expenseHandler.GetByID)
-				expenses.POST("", expenseHandler.Create)
-				expenses.PUT("/:id", expenseHandler.Update)
-				expenses.DELETE("/:id", expenseHandler.Delete)
+				categories.GET("", expensesRead, categoryHandler.GetAll)
+				categories.POST("", expensesWrite, categoryHandler.Create)
+				categories.PUT("/:id", expensesWrite, categoryHandler.Update)
+				categories.DELETE("/:id", expensesWrite, categoryHandler.Delete)
+			}
+
+			// Tags
+			tags := protected.Group("/tags")
+			tags.Use(middleware.RequireVerifiedEmail(cfg.RequireVerifiedEmail))
+			{
+				tags.GET("", expensesRead, tagHandler.GetAll)
+				tags.POST("", expensesWrite, tagHandler.Create)
+				tags.PUT("/:id", expensesWrite, tagHandler.Rename)
+				tags.POST("/:id/merge", expensesWrite, tagHandler.Merge)
+				tags.DELETE("/:id", expensesWrite, tagHandler.Delete)
+			}
+
+			// Accounts
+			accounts := protected.Group("/accounts")
+			accounts.Use(middleware.RequireVerifiedEmail(cfg.RequireVerifiedEmail))
+			{
+				accounts.GET("", expensesRead, accountHandler.GetAll)
+				accounts.GET("/:id", expensesRead, accountHandler.GetByID)
+				accounts.GET("/:id/history", expensesRead, accountHandler.GetHistory)
+				accounts.POST("", expensesWrite, accountHandler.Create)
+				accounts.PUT("/:id", expensesWrite, accountHandler.Update)
+				accounts.DELETE("/:id", expensesWrite, accountHandler.Delete)
+			}
+
+			// Transfers
+			transfers := protected.Group("/transfers")
+			transfers.Use(middleware.RequireVerifiedEmail(cfg.RequireVerifiedEmail))
+			{
+				transfers.GET("", expensesRead, transferHandler.GetAll)
+				transfers.GET("/:id", expensesRead, transferHandler.GetByID)
+				transfers.POST("", expensesWrite, transferHandler.Create)
+				transfers.DELETE("/:id", expensesWrite, transferHandler.Delete)
+			}
+
+			// Recurring expenses
+			recurring := protected.Group("/recurring")
+			recurring.Use(middleware.RequireVerifiedEmail(cfg.RequireVerifiedEmail))
+			{
+				recurring.GET("", expensesRead, recurringHandler.GetAll)
+				recurring.GET("/:id", expensesRead, recurringHandler.GetByID)
+				recurring.POST("", expensesWrite, recurringHandler.Create)
+				recurring.PUT("/:id", expensesWrite, recurringHandler.Update)
+				recurring.DELETE("/:id", expensesWrite, recurringHandler.Delete)
+				recurring.POST("/:id/skip", expensesWrite, recurringHandler.Skip)
+				recurring.POST("/:id/pause", expensesWrite, recurringHandler.Pause)
+				recurring.POST("/:id/resume", expensesWrite, recurringHandler.Resume)
+			}
+
+			// Budgets
+			budgets := protected.Group("/budgets")
+			budgets.Use(middleware.RequireVerifiedEmail(cfg.RequireVerifiedEmail))
+			{
+				budgets.GET("", expensesRead, budgetHandler.GetAll)
+				budgets.GET("/status", statsRead, budgetHandler.GetStatus)
+				budgets.GET("/alerts", expensesRead, budgetHandler.GetAlerts)
+				budgets.POST("", expensesWrite, budgetHandler.Create)
+				budgets.PUT("/:id", expensesWrite, budgetHandler.Update)
+				budgets.DELETE("/:id", expensesWrite, budgetHandler.Delete)
+			}
+
+			// Savings goals
+			goals := protected.Group("/goals")
+			goals.Use(middleware.RequireVerifiedEmail(cfg.RequireVerifiedEmail))
+			{
+				goals.GET("", expensesRead, goalHandler.GetAll)
+				goals.GET("/:id", expensesRead, goalHandler.GetByID)
+				goals.GET("/:id/projection", expensesRead, goalHandler.GetProjection)
+				goals.GET("/:id/contributions", expensesRead, goalHandler.GetContributions)
+				goals.POST("", expensesWrite, goalHandler.Create)
+				goals.PUT("/:id", expensesWrite, goalHandler.Update)
+				goals.DELETE("/:id", expensesWrite, goalHandler.Delete)
+				goals.POST("/:id/contributions", expensesWrite, goalHandler.AddContribution)
+				goals.DELETE("/:id/contributions/:contribution_id", expensesWrite, goalHandler.DeleteContribution)
+			}
+
+			// Ledgers
+			// Who can see a ledger is managed from a signed-in session only;
+			// personal access tokens can read ledgers but not change them.
+			ledgers := protected.Group("/ledgers")
+			ledgers.Use(middleware.RequireVerifiedEmail(cfg.RequireVerifiedEmail))
+			{
+				session := middleware.RequireSession()
+				ledgers.GET("", expensesRead, ledgerHandler.GetAll)
+				ledgers.GET("/invitations", session, ledgerHandler.GetMyInvitations)
+				ledgers.POST("/invitations/:id/accept", session, ledgerHandler.AcceptInvitation)
+				ledgers.POST("/invitations/:id/decline", session, ledgerHandler.DeclineInvitation)
+				ledgers.GET("/:id", expensesRead, ledgerHandler.GetByID)
+				ledgers.GET("/:id/members", expensesRead, ledgerHandler.GetMembers)
+				ledgers.GET("/:id/invitations", session, ledgerHandler.GetInvitations)
+				ledgers.POST("", session, ledgerHandler.Create)
+				ledgers.PUT("/:id", session, ledgerHandler.Update)
+				ledgers.DELETE("/:id", session, ledgerHandler.Delete)
+				ledgers.PUT("/:id/members/:user_id", session, ledgerHandler.UpdateMember)
+				ledgers.DELETE("/:id/members/:user_id", session, ledgerHandler.RemoveMember)
+				ledgers.POST("/:id/invitations", session, ledgerHandler.Invite)
+				ledgers.DELETE("/:id/invitations/:invitation_id", session, ledgerHandler.RevokeInvitation)
+			}
+
+			// Exchange rates
+			protected.GET("/exchange-rates", expensesRead, currencyHandler.GetRates)
+
+			// Admin routes
+			admin := protected.Group("/admin")
+			admin.Use(middleware.RequireSession(), middleware.RequireRole(models.RoleAdmin))
+			{
+				admin.GET("/users", adminHandler.ListUsers)
+				admin.GET("/users/:id", adminHandler.GetUser)
+				admin.PUT("/users/:id/role", adminHandler.SetRole)
+				admin.POST("/users/:id/disable", adminHandler.DisableUser)
+				admin.POST("/users/:id/enable", adminHandler.EnableUser)
+				admin.POST("/users/:id/reset-password", adminHandler.ForcePasswordReset)
+				admin.GET("/stats", adminHandler.GetStats)
+				admin.POST("/exchange-rates", currencyHandler.CreateRate)
+				admin.POST("/exchange-rates/import", currencyHandler.ImportRates)
 			}
 		}
 	}
 
+	// Background jobs
+	jobs, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	if cfg.RecurringEnabled {
+		services.StartRecurringScheduler(jobs, recurringService, cfg.RecurringInterval)
+	}
+
 	// Server setup with graceful shutdown
 	srv := &http.Server{
 		Addr:    ":" + cfg.Port,
@@ -110,6 +392,7 @@ func main() {
 	<-quit
 
 	log.Println("Shutting down server...")
+	stopJobs()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
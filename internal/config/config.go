@@ -1,44 +1,324 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
+type OIDCProviderConfig struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
 type Config struct {
-	Port        string
-	DatabaseURL string
-	DBHost      string
-	DBPort      string
-	DBUser      string
-	DBPassword  string
-	DBName      string
-	DBSSLMode   string
-	CORSOrigins string
-	JWTSecret   string
+	Port                   string
+	DatabaseURL            string
+	DBHost                 string
+	DBPort                 string
+	DBUser                 string
+	DBPassword             string
+	DBName                 string
+	DBSSLMode              string
+	CORSOrigins            string
+	JWTAlgorithm           string
+	JWTIssuer              string
+	JWTKeyRotation         time.Duration
+	AccessTokenTTL         time.Duration
+	RefreshTokenTTL        time.Duration
+	RevocationSyncInterval time.Duration
+	PasswordResetTTL       time.Duration
+	EmailVerificationTTL   time.Duration
+	RequireVerifiedEmail   bool
+	TOTPIssuer             string
+	MFAChallengeTTL        time.Duration
+	LoginMaxAttempts       int
+	LoginIPMaxAttempts     int
+	LoginAttemptWindow     time.Duration
+	LoginLockoutDuration   time.Duration
+	LoginDelayBase         time.Duration
+	LoginAttemptStore      string
+	SessionTouchInterval   time.Duration
+	ReauthWindow           time.Duration
+	MagicLinkTTL           time.Duration
+	MagicLinkMaxRequests   int
+	MagicLinkWindow        time.Duration
+	AppURL                 string
+	MailDriver             string
+	MailFrom               string
+	MailFilePath           string
+	SMTPHost               string
+	SMTPPort               string
+	SMTPUsername           string
+	SMTPPassword           string
+	OIDCProviders          []OIDCProviderConfig
+	AdminEmails            []string
+	ExchangeRatesFile      string
+	MoneyJSONFormat        string
+	RecurringEnabled       bool
+	RecurringInterval      time.Duration
+	LedgerInvitationTTL    time.Duration
 }
 
 func Load() (*Config, error) {
 	godotenv.Load()
 
+	accessTokenTTL, err := getEnvPositiveDuration("ACCESS_TOKEN_TTL", 24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	refreshTokenTTL, err := getEnvPositiveDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	revocationSyncInterval, err := getEnvPositiveDuration("REVOCATION_SYNC_INTERVAL", 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	passwordResetTTL, err := getEnvPositiveDuration("PASSWORD_RESET_TTL", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	emailVerificationTTL, err := getEnvPositiveDuration("EMAIL_VERIFICATION_TTL", 48*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	requireVerifiedEmail, err := getEnvBool("REQUIRE_VERIFIED_EMAIL", false)
+	if err != nil {
+		return nil, err
+	}
+
+	mfaChallengeTTL, err := getEnvPositiveDuration("MFA_CHALLENGE_TTL", 5*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	loginMaxAttempts, err := getEnvPositiveInt("LOGIN_MAX_ATTEMPTS", 5)
+	if err != nil {
+		return nil, err
+	}
+	loginIPMaxAttempts, err := getEnvPositiveInt("LOGIN_IP_MAX_ATTEMPTS", 20)
+	if err != nil {
+		return nil, err
+	}
+	loginAttemptWindow, err := getEnvPositiveDuration("LOGIN_ATTEMPT_WINDOW", 15*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	loginLockoutDuration, err := getEnvPositiveDuration("LOGIN_LOCKOUT_DURATION", 15*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	loginDelayBase, err := getEnvDuration("LOGIN_DELAY_BASE", time.Second)
+	if err != nil {
+		return nil, err
+	}
+	if loginDelayBase < 0 {
+		return nil, fmt.Errorf("invalid LOGIN_DELAY_BASE: must not be negative")
+	}
+
+	sessionTouchInterval, err := getEnvPositiveDuration("SESSION_TOUCH_INTERVAL", 5*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	reauthWindow, err := getEnvPositiveDuration("REAUTH_WINDOW", 10*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	magicLinkTTL, err := getEnvPositiveDuration("MAGIC_LINK_TTL", 15*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	magicLinkMaxRequests, err := getEnvInt("MAGIC_LINK_MAX_REQUESTS", 3)
+	if err != nil {
+		return nil, err
+	}
+	magicLinkWindow, err := getEnvPositiveDuration("MAGIC_LINK_WINDOW", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	jwtAlgorithm := getEnv("JWT_ALGORITHM", "RS256")
+	if jwtAlgorithm != "RS256" && jwtAlgorithm != "EdDSA" {
+		return nil, fmt.Errorf("invalid JWT_ALGORITHM: %s (use RS256 or EdDSA)", jwtAlgorithm)
+	}
+	jwtKeyRotation, err := getEnvPositiveDuration("JWT_KEY_ROTATION", 30*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	recurringEnabled, err := getEnvBool("RECURRING_ENABLED", true)
+	if err != nil {
+		return nil, err
+	}
+	recurringInterval, err := getEnvPositiveDuration("RECURRING_INTERVAL", time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	ledgerInvitationTTL, err := getEnvPositiveDuration("LEDGER_INVITATION_TTL", 7*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	oidcProviders, err := loadOIDCProviders()
+	if err != nil {
+		return nil, err
+	}
+
 	return &Config{
-		Port:        getEnv("PORT", "8080"),
-		DatabaseURL: os.Getenv("DATABASE_URL"),
-		DBHost:      getEnv("DB_HOST", "localhost"),
-		DBPort:      getEnv("DB_PORT", "5432"),
-		DBUser:      getEnv("DB_USER", "postgres"),
-		DBPassword:  getEnv("DB_PASSWORD", "password"),
-		DBName:      getEnv("DB_NAME", "mamonedz"),
-		DBSSLMode:   getEnv("DB_SSLMODE", "disable"),
-		CORSOrigins: getEnv("CORS_ORIGINS", "http://localhost:3000"),
-		JWTSecret:   getEnv("JWT_SECRET", "your-secret-key-change-in-production"),
+		Port:                   getEnv("PORT", "8080"),
+		DatabaseURL:            os.Getenv("DATABASE_URL"),
+		DBHost:                 getEnv("DB_HOST", "localhost"),
+		DBPort:                 getEnv("DB_PORT", "5432"),
+		DBUser:                 getEnv("DB_USER", "postgres"),
+		DBPassword:             getEnv("DB_PASSWORD", "password"),
+		DBName:                 getEnv("DB_NAME", "mamonedz"),
+		DBSSLMode:              getEnv("DB_SSLMODE", "disable"),
+		CORSOrigins:            getEnv("CORS_ORIGINS", "http://localhost:3000"),
+		JWTAlgorithm:           jwtAlgorithm,
+		JWTIssuer:              getEnv("JWT_ISSUER", "mamonedz"),
+		JWTKeyRotation:         jwtKeyRotation,
+		AccessTokenTTL:         accessTokenTTL,
+		RefreshTokenTTL:        refreshTokenTTL,
+		RevocationSyncInterval: revocationSyncInterval,
+		PasswordResetTTL:       passwordResetTTL,
+		EmailVerificationTTL:   emailVerificationTTL,
+		RequireVerifiedEmail:   requireVerifiedEmail,
+		TOTPIssuer:             getEnv("TOTP_ISSUER", "Mamonedz"),
+		MFAChallengeTTL:        mfaChallengeTTL,
+		LoginMaxAttempts:       loginMaxAttempts,
+		LoginIPMaxAttempts:     loginIPMaxAttempts,
+		LoginAttemptWindow:     loginAttemptWindow,
+		LoginLockoutDuration:   loginLockoutDuration,
+		LoginDelayBase:         loginDelayBase,
+		LoginAttemptStore:      getEnv("LOGIN_ATTEMPT_STORE", "memory"),
+		SessionTouchInterval:   sessionTouchInterval,
+		ReauthWindow:           reauthWindow,
+		MagicLinkTTL:           magicLinkTTL,
+		MagicLinkMaxRequests:   magicLinkMaxRequests,
+		MagicLinkWindow:        magicLinkWindow,
+		AppURL:                 getEnv("APP_URL", "http://localhost:3000"),
+		MailDriver:             getEnv("MAIL_DRIVER", "log"),
+		MailFrom:               getEnv("MAIL_FROM", "Mamonedz <no-reply@mamonedz.local>"),
+		MailFilePath:           os.Getenv("MAIL_FILE_PATH"),
+		SMTPHost:               getEnv("SMTP_HOST", "localhost"),
+		SMTPPort:               getEnv("SMTP_PORT", "587"),
+		SMTPUsername:           os.Getenv("SMTP_USERNAME"),
+		SMTPPassword:           os.Getenv("SMTP_PASSWORD"),
+		OIDCProviders:          oidcProviders,
+		AdminEmails:            strings.Split(os.Getenv("ADMIN_EMAILS"), ","),
+		ExchangeRatesFile:      os.Getenv("EXCHANGE_RATES_FILE"),
+		MoneyJSONFormat:        getEnv("MONEY_JSON_FORMAT", "string"),
+		RecurringEnabled:       recurringEnabled,
+		RecurringInterval:      recurringInterval,
+		LedgerInvitationTTL:    ledgerInvitationTTL,
 	}, nil
 }
 
+// loadOIDCProviders reads the providers named in OIDC_PROVIDERS. Each name
+// is configured through OIDC_<NAME>_ISSUER, _CLIENT_ID, _CLIENT_SECRET,
+// _REDIRECT_URL and optionally _SCOPES.
+func loadOIDCProviders() ([]OIDCProviderConfig, error) {
+	var providers []OIDCProviderConfig
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		provider := OIDCProviderConfig{
+			Name:         name,
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+			Scopes:       strings.Fields(getEnv(prefix+"SCOPES", "openid email profile")),
+		}
+		if provider.Issuer == "" || provider.ClientID == "" || provider.RedirectURL == "" {
+			return nil, fmt.Errorf("OIDC provider %s needs %sISSUER, %sCLIENT_ID and %sREDIRECT_URL", name, prefix, prefix, prefix)
+		}
+		providers = append(providers, provider)
+	}
+	return providers, nil
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
+
+func getEnvDuration(key string, defaultValue time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return d, nil
+}
+
+// getEnvPositiveDuration is getEnvDuration for TTLs and intervals, where zero
+// or a negative value would expire everything at once or spin a ticker.
+func getEnvPositiveDuration(key string, defaultValue time.Duration) (time.Duration, error) {
+	d, err := getEnvDuration(key, defaultValue)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("invalid %s: must be positive", key)
+	}
+	return d, nil
+}
+
+func getEnvBool(key string, defaultValue bool) (bool, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return b, nil
+}
+
+func getEnvInt(key string, defaultValue int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return n, nil
+}
+
+func getEnvPositiveInt(key string, defaultValue int) (int, error) {
+	n, err := getEnvInt(key, defaultValue)
+	if err != nil {
+		return 0, err
+	}
+	if n <= 0 {
+		return 0, fmt.Errorf("invalid %s: must be positive", key)
+	}
+	return n, nil
+}
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"mamonedz/internal/models"
+	"mamonedz/internal/services"
+	"mamonedz/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type AccountHandler struct {
+	service  services.AccountService
+	validate *validator.Validate
+}
+
+func NewAccountHandler(service services.AccountService) *AccountHandler {
+	return &AccountHandler{
+		service:  service,
+		validate: validator.New(),
+	}
+}
+
+func (h *AccountHandler) Create(c *gin.Context) {
+	var req models.CreateAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondInvalidBody(c, err)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		response.BadRequest(c, "Validation failed: "+err.Error())
+		return
+	}
+
+	account, err := h.service.Create(getUserID(c), &req)
+	if err != nil {
+		response.InternalError(c, "Failed to create account")
+		return
+	}
+
+	response.Created(c, account, "Account created successfully")
+}
+
+func (h *AccountHandler) GetAll(c *gin.Context) {
+	asOf, ok := parseAsOf(c)
+	if !ok {
+		return
+	}
+
+	accounts, err := h.service.GetAll(getUserID(c), asOf)
+	if err != nil {
+		response.InternalError(c, "Failed to get accounts")
+		return
+	}
+
+	response.Success(c, accounts)
+}
+
+func (h *AccountHandler) GetByID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid account ID")
+		return
+	}
+
+	asOf, ok := parseAsOf(c)
+	if !ok {
+		return
+	}
+
+	account, err := h.service.GetByID(id, getUserID(c), asOf)
+	if err != nil {
+		if errors.Is(err, services.ErrAccountNotFound) {
+			response.NotFound(c, "Account not found")
+			return
+		}
+		response.InternalError(c, "Failed to get account")
+		return
+	}
+
+	response.Success(c, account)
+}
+
+func (h *AccountHandler) Update(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid account ID")
+		return
+	}
+
+	var req models.UpdateAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondInvalidBody(c, err)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		response.BadRequest(c, "Validation failed: "+err.Error())
+		return
+	}
+
+	account, err := h.service.Update(id, getUserID(c), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrAccountNotFound):
+			response.NotFound(c, "Account not found")
+		case errors.Is(err, services.ErrAccountCurrencyLocked):
+			response.Error(c, 409, "Account has transactions and cannot change currency")
+		default:
+			response.InternalError(c, "Failed to update account")
+		}
+		return
+	}
+
+	response.SuccessWithMessage(c, account, "Account updated successfully")
+}
+
+func (h *AccountHandler) Delete(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid account ID")
+		return
+	}
+
+	if err := h.service.Delete(id, getUserID(c)); err != nil {
+		switch {
+		case errors.Is(err, services.ErrAccountNotFound):
+			response.NotFound(c, "Account not found")
+		case errors.Is(err, services.ErrAccountInUse):
+			response.Error(c, 409, "Account has transactions and cannot be deleted")
+		default:
+			response.InternalError(c, "Failed to delete account")
+		}
+		return
+	}
+
+	response.SuccessWithMessage(c, nil, "Account deleted successfully")
+}
+
+func (h *AccountHandler) GetHistory(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid account ID")
+		return
+	}
+
+	limit, offset := 20, 0
+	if l, err := strconv.Atoi(c.Query("limit")); err == nil && l > 0 {
+		limit = l
+	}
+	if o, err := strconv.Atoi(c.Query("offset")); err == nil && o >= 0 {
+		offset = o
+	}
+
+	entries, total, err := h.service.GetHistory(id, getUserID(c), limit, offset)
+	if err != nil {
+		if errors.Is(err, services.ErrAccountNotFound) {
+			response.NotFound(c, "Account not found")
+			return
+		}
+		response.InternalError(c, "Failed to get account history")
+		return
+	}
+
+	response.SuccessWithMeta(c, entries, &response.Meta{
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	})
+}
+
+// parseAsOf reads the optional as_of date. Balances as of a day include all
+// of that day's transactions. It writes the error response and returns false
+// when the date is malformed.
+func parseAsOf(c *gin.Context) (*time.Time, bool) {
+	asOf := c.Query("as_of")
+	if asOf == "" {
+		return nil, true
+	}
+	t, err := time.Parse("2006-01-02", asOf)
+	if err != nil {
+		response.BadRequest(c, "Invalid as_of date, use YYYY-MM-DD")
+		return nil, false
+	}
+	return &t, true
+}
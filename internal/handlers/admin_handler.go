@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"mamonedz/internal/models"
+	"mamonedz/internal/services"
+	"mamonedz/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type AdminHandler struct {
+	service  services.AdminService
+	validate *validator.Validate
+}
+
+func NewAdminHandler(service services.AdminService) *AdminHandler {
+	return &AdminHandler{
+		service:  service,
+		validate: validator.New(),
+	}
+}
+
+func (h *AdminHandler) ListUsers(c *gin.Context) {
+	filter := &models.UserFilter{
+		Search: c.Query("search"),
+		Role:   c.Query("role"),
+		Limit:  20,
+		Offset: 0,
+	}
+
+	if disabled := c.Query("disabled"); disabled != "" {
+		if d, err := strconv.ParseBool(disabled); err == nil {
+			filter.Disabled = &d
+		}
+	}
+	if limit := c.Query("limit"); limit != "" {
+		if l, err := strconv.Atoi(limit); err == nil && l > 0 {
+			filter.Limit = l
+		}
+	}
+	if offset := c.Query("offset"); offset != "" {
+		if o, err := strconv.Atoi(offset); err == nil && o >= 0 {
+			filter.Offset = o
+		}
+	}
+
+	users, total, err := h.service.ListUsers(filter)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidRole) {
+			response.BadRequest(c, "Invalid role")
+			return
+		}
+		response.InternalError(c, "Failed to get users")
+		return
+	}
+
+	response.SuccessWithMeta(c, users, &response.Meta{
+		Total:  total,
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	})
+}
+
+func (h *AdminHandler) GetUser(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid user ID")
+		return
+	}
+
+	user, err := h.service.GetUser(id)
+	if err != nil {
+		if errors.Is(err, services.ErrUserNotFound) {
+			response.NotFound(c, "User not found")
+			return
+		}
+		response.InternalError(c, "Failed to get user")
+		return
+	}
+
+	response.Success(c, user)
+}
+
+func (h *AdminHandler) DisableUser(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid user ID")
+		return
+	}
+
+	if err := h.service.DisableUser(id, getUserID(c)); err != nil {
+		switch {
+		case errors.Is(err, services.ErrUserNotFound):
+			response.NotFound(c, "User not found")
+		case errors.Is(err, services.ErrCannotModifySelf):
+			response.BadRequest(c, "You cannot disable your own account")
+		default:
+			response.InternalError(c, "Failed to disable user")
+		}
+		return
+	}
+
+	response.SuccessWithMessage(c, nil, "User disabled")
+}
+
+func (h *AdminHandler) EnableUser(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid user ID")
+		return
+	}
+
+	if err := h.service.EnableUser(id); err != nil {
+		if errors.Is(err, services.ErrUserNotFound) {
+			response.NotFound(c, "User not found")
+			return
+		}
+		response.InternalError(c, "Failed to enable user")
+		return
+	}
+
+	response.SuccessWithMessage(c, nil, "User enabled")
+}
+
+func (h *AdminHandler) ForcePasswordReset(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid user ID")
+		return
+	}
+
+	if err := h.service.ForcePasswordReset(id); err != nil {
+		if errors.Is(err, services.ErrUserNotFound) {
+			response.NotFound(c, "User not found")
+			return
+		}
+		response.InternalError(c, "Failed to reset password")
+		return
+	}
+
+	response.SuccessWithMessage(c, nil, "Password reset, the user has been emailed a link to choose a new one")
+}
+
+func (h *AdminHandler) SetRole(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid user ID")
+		return
+	}
+
+	var req models.UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body")
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		response.BadRequest(c, "Validation failed: "+err.Error())
+		return
+	}
+
+	if err := h.service.SetRole(id, getUserID(c), &req); err != nil {
+		switch {
+		case errors.Is(err, services.ErrUserNotFound):
+			response.NotFound(c, "User not found")
+		case errors.Is(err, services.ErrCannotModifySelf):
+			response.BadRequest(c, "You cannot change your own role")
+		case errors.Is(err, services.ErrInvalidRole):
+			response.BadRequest(c, "Invalid role")
+		default:
+			response.InternalError(c, "Failed to update role")
+		}
+		return
+	}
+
+	response.SuccessWithMessage(c, nil, "Role updated")
+}
+
+func (h *AdminHandler) GetStats(c *gin.Context) {
+	stats, err := h.service.GetStats()
+	if err != nil {
+		response.InternalError(c, "Failed to get platform statistics")
+		return
+	}
+
+	response.Success(c, stats)
+}
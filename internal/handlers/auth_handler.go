@@ -2,6 +2,9 @@ package handlers
 
 import (
 	"errors"
+	"math"
+	"net/http"
+	"strconv"
 
 	"mamonedz/internal/models"
 	"mamonedz/internal/services"
@@ -9,6 +12,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
 )
 
 type AuthHandler struct {
@@ -23,6 +27,31 @@ func NewAuthHandler(service services.AuthService) *AuthHandler {
 	}
 }
 
+func clientInfo(c *gin.Context) *models.ClientInfo {
+	return &models.ClientInfo{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+}
+
+// respondThrottled writes a 423 or 429 response with Retry-After when err is
+// a login throttling error, and reports whether it did so.
+func respondThrottled(c *gin.Context, err error) bool {
+	var throttled *services.LoginThrottledError
+	if !errors.As(err, &throttled) {
+		return false
+	}
+
+	seconds := int(math.Ceil(throttled.RetryAfter.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	if throttled.Locked {
+		response.Error(c, http.StatusLocked, "Account temporarily locked due to too many failed login attempts")
+	} else {
+		response.Error(c, http.StatusTooManyRequests, "Too many login attempts, please try again later")
+	}
+	return true
+}
+
 func (h *AuthHandler) Register(c *gin.Context) {
 	var req models.RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -35,7 +64,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	result, err := h.service.Register(&req)
+	result, err := h.service.Register(&req, clientInfo(c))
 	if err != nil {
 		if errors.Is(err, services.ErrEmailAlreadyExists) {
 			response.BadRequest(c, "Email already exists")
@@ -60,19 +89,469 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	result, err := h.service.Login(&req)
+	result, err := h.service.Login(&req, clientInfo(c))
 	if err != nil {
+		if respondThrottled(c, err) {
+			return
+		}
 		if errors.Is(err, services.ErrInvalidCredentials) {
 			response.Error(c, 401, "Invalid email or password")
 			return
 		}
+		if errors.Is(err, services.ErrAccountDisabled) {
+			response.Error(c, 403, "Account disabled")
+			return
+		}
+		response.InternalError(c, "Failed to login")
+		return
+	}
+
+	if result.MFARequired {
+		response.SuccessWithMessage(c, result, "Two-factor authentication required")
+		return
+	}
+
+	response.SuccessWithMessage(c, result, "Login successful")
+}
+
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req models.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body")
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		response.BadRequest(c, "Validation failed: "+err.Error())
+		return
+	}
+
+	result, err := h.service.Refresh(&req, clientInfo(c))
+	if err != nil {
+		if errors.Is(err, services.ErrRefreshReused) {
+			response.Error(c, 401, "Refresh token reuse detected, please login again")
+			return
+		}
+		if errors.Is(err, services.ErrInvalidRefresh) || errors.Is(err, services.ErrUserNotFound) {
+			response.Error(c, 401, "Invalid or expired refresh token")
+			return
+		}
+		if errors.Is(err, services.ErrAccountDisabled) {
+			response.Error(c, 403, "Account disabled")
+			return
+		}
+		response.InternalError(c, "Failed to refresh token")
+		return
+	}
+
+	response.SuccessWithMessage(c, result, "Token refreshed successfully")
+}
+
+func (h *AuthHandler) Logout(c *gin.Context) {
+	var req models.LogoutRequest
+	// The body is optional; a missing refresh token only revokes the access token.
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest(c, "Invalid request body")
+			return
+		}
+	}
+
+	claims, _ := c.Get("token_claims")
+	if err := h.service.Logout(claims.(*services.TokenClaims), &req); err != nil {
+		response.InternalError(c, "Failed to logout")
+		return
+	}
+
+	response.SuccessWithMessage(c, nil, "Logout successful")
+}
+
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
+	if err := h.service.LogoutAll(getUserID(c)); err != nil {
+		response.InternalError(c, "Failed to logout")
+		return
+	}
+
+	response.SuccessWithMessage(c, nil, "Logged out from all devices")
+}
+
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req models.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body")
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		response.BadRequest(c, "Validation failed: "+err.Error())
+		return
+	}
+
+	if err := h.service.ForgotPassword(&req); err != nil {
+		response.InternalError(c, "Failed to send password reset email")
+		return
+	}
+
+	response.SuccessWithMessage(c, nil, "If the email is registered, a password reset link has been sent")
+}
+
+func (h *AuthHandler) SendMagicLink(c *gin.Context) {
+	var req models.MagicLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body")
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		response.BadRequest(c, "Validation failed: "+err.Error())
+		return
+	}
+
+	if err := h.service.SendMagicLink(&req); err != nil {
+		if respondThrottled(c, err) {
+			return
+		}
+		response.InternalError(c, "Failed to send login link")
+		return
+	}
+
+	response.SuccessWithMessage(c, nil, "If the email is registered, a login link has been sent")
+}
+
+func (h *AuthHandler) LoginWithMagicLink(c *gin.Context) {
+	var req models.MagicLinkLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body")
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		response.BadRequest(c, "Validation failed: "+err.Error())
+		return
+	}
+
+	result, err := h.service.LoginWithMagicLink(&req, clientInfo(c))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidMagicLink) {
+			response.Error(c, 401, "Invalid or expired login link")
+			return
+		}
+		if errors.Is(err, services.ErrAccountDisabled) {
+			response.Error(c, 403, "Account disabled")
+			return
+		}
 		response.InternalError(c, "Failed to login")
 		return
 	}
 
+	if result.MFARequired {
+		response.SuccessWithMessage(c, result, "Two-factor authentication required")
+		return
+	}
+
+	response.SuccessWithMessage(c, result, "Login successful")
+}
+
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req models.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body")
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		response.BadRequest(c, "Validation failed: "+err.Error())
+		return
+	}
+
+	if err := h.service.ResetPassword(&req); err != nil {
+		if errors.Is(err, services.ErrInvalidResetToken) {
+			response.BadRequest(c, "Invalid or expired reset token")
+			return
+		}
+		response.InternalError(c, "Failed to reset password")
+		return
+	}
+
+	response.SuccessWithMessage(c, nil, "Password reset successfully")
+}
+
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		response.BadRequest(c, "Token is required")
+		return
+	}
+
+	if err := h.service.VerifyEmail(token); err != nil {
+		if errors.Is(err, services.ErrInvalidVerifyToken) {
+			response.BadRequest(c, "Invalid or expired verification token")
+			return
+		}
+		if errors.Is(err, services.ErrEmailAlreadyExists) {
+			response.BadRequest(c, "Email already exists")
+			return
+		}
+		response.InternalError(c, "Failed to verify email")
+		return
+	}
+
+	response.SuccessWithMessage(c, nil, "Email verified successfully")
+}
+
+func (h *AuthHandler) ResendVerification(c *gin.Context) {
+	if err := h.service.ResendVerification(getUserID(c)); err != nil {
+		if errors.Is(err, services.ErrAlreadyVerified) {
+			response.BadRequest(c, "Email already verified")
+			return
+		}
+		response.InternalError(c, "Failed to send verification email")
+		return
+	}
+
+	response.SuccessWithMessage(c, nil, "Verification email sent")
+}
+
+func (h *AuthHandler) SetupMFA(c *gin.Context) {
+	result, err := h.service.SetupMFA(getUserID(c))
+	if err != nil {
+		if errors.Is(err, services.ErrMFAAlreadyEnabled) {
+			response.BadRequest(c, "Two-factor authentication already enabled")
+			return
+		}
+		response.InternalError(c, "Failed to setup two-factor authentication")
+		return
+	}
+
+	response.Success(c, result)
+}
+
+func (h *AuthHandler) EnableMFA(c *gin.Context) {
+	var req models.MFAEnableRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body")
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		response.BadRequest(c, "Validation failed: "+err.Error())
+		return
+	}
+
+	result, err := h.service.EnableMFA(getUserID(c), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrMFAAlreadyEnabled):
+			response.BadRequest(c, "Two-factor authentication already enabled")
+		case errors.Is(err, services.ErrMFASetupRequired):
+			response.BadRequest(c, "Call /auth/mfa/setup first")
+		case errors.Is(err, services.ErrInvalidMFACode):
+			response.BadRequest(c, "Invalid two-factor code")
+		default:
+			response.InternalError(c, "Failed to enable two-factor authentication")
+		}
+		return
+	}
+
+	response.SuccessWithMessage(c, result, "Two-factor authentication enabled. Store your recovery codes safely")
+}
+
+func (h *AuthHandler) DisableMFA(c *gin.Context) {
+	var req models.MFADisableRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body")
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		response.BadRequest(c, "Validation failed: "+err.Error())
+		return
+	}
+
+	if err := h.service.DisableMFA(getUserID(c), &req); err != nil {
+		switch {
+		case errors.Is(err, services.ErrMFANotEnabled):
+			response.BadRequest(c, "Two-factor authentication not enabled")
+		case errors.Is(err, services.ErrInvalidCredentials):
+			response.Error(c, 401, "Invalid password")
+		case errors.Is(err, services.ErrInvalidMFACode):
+			response.Error(c, 401, "Invalid two-factor code")
+		default:
+			response.InternalError(c, "Failed to disable two-factor authentication")
+		}
+		return
+	}
+
+	response.SuccessWithMessage(c, nil, "Two-factor authentication disabled")
+}
+
+func (h *AuthHandler) VerifyMFA(c *gin.Context) {
+	var req models.MFAVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body")
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		response.BadRequest(c, "Validation failed: "+err.Error())
+		return
+	}
+
+	result, err := h.service.VerifyMFA(&req, clientInfo(c))
+	if err != nil {
+		if respondThrottled(c, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, services.ErrInvalidToken), errors.Is(err, services.ErrUserNotFound):
+			response.Error(c, 401, "Invalid or expired MFA token")
+		case errors.Is(err, services.ErrInvalidMFACode):
+			response.Error(c, 401, "Invalid two-factor code")
+		case errors.Is(err, services.ErrAccountDisabled):
+			response.Error(c, 403, "Account disabled")
+		default:
+			response.InternalError(c, "Failed to verify two-factor code")
+		}
+		return
+	}
+
 	response.SuccessWithMessage(c, result, "Login successful")
 }
 
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	var req models.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body")
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		response.BadRequest(c, "Validation failed: "+err.Error())
+		return
+	}
+
+	claims, _ := c.Get("token_claims")
+	result, err := h.service.ChangePassword(claims.(*services.TokenClaims), &req, clientInfo(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidCredentials):
+			response.Error(c, 401, "Current password is incorrect")
+		case errors.Is(err, services.ErrReauthRequired):
+			response.Error(c, 403, "Sign in again to confirm this change")
+		default:
+			response.InternalError(c, "Failed to change password")
+		}
+		return
+	}
+
+	response.SuccessWithMessage(c, result, "Password changed successfully")
+}
+
+func (h *AuthHandler) ChangeEmail(c *gin.Context) {
+	var req models.ChangeEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body")
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		response.BadRequest(c, "Validation failed: "+err.Error())
+		return
+	}
+
+	claims, _ := c.Get("token_claims")
+	if err := h.service.ChangeEmail(claims.(*services.TokenClaims), &req); err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidCredentials):
+			response.Error(c, 401, "Password is incorrect")
+		case errors.Is(err, services.ErrReauthRequired):
+			response.Error(c, 403, "Sign in again to confirm this change")
+		case errors.Is(err, services.ErrEmailAlreadyExists):
+			response.BadRequest(c, "Email already exists")
+		case errors.Is(err, services.ErrSameEmail):
+			response.BadRequest(c, "New email is the same as the current one")
+		default:
+			response.InternalError(c, "Failed to change email")
+		}
+		return
+	}
+
+	response.SuccessWithMessage(c, nil, "Confirmation link sent to the new email address")
+}
+
+func (h *AuthHandler) DeleteAccount(c *gin.Context) {
+	var req models.DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body")
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		response.BadRequest(c, "Validation failed: "+err.Error())
+		return
+	}
+
+	claims, _ := c.Get("token_claims")
+	if err := h.service.DeleteAccount(claims.(*services.TokenClaims), &req); err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidCredentials):
+			response.Error(c, 401, "Password is incorrect")
+		case errors.Is(err, services.ErrReauthRequired):
+			response.Error(c, 403, "Sign in again to confirm this change")
+		case errors.Is(err, services.ErrOwnsSharedLedgers):
+			response.Error(c, 409, "You own ledgers with other members; remove them first")
+		default:
+			response.InternalError(c, "Failed to delete account")
+		}
+		return
+	}
+
+	response.SuccessWithMessage(c, nil, "Account deleted successfully")
+}
+
+func (h *AuthHandler) GetSessions(c *gin.Context) {
+	claims, _ := c.Get("token_claims")
+	sessions, err := h.service.GetSessions(getUserID(c), claims.(*services.TokenClaims).SessionID)
+	if err != nil {
+		response.InternalError(c, "Failed to get sessions")
+		return
+	}
+
+	response.Success(c, sessions)
+}
+
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid session ID")
+		return
+	}
+
+	if err := h.service.RevokeSession(id, getUserID(c)); err != nil {
+		if errors.Is(err, services.ErrSessionNotFound) {
+			response.NotFound(c, "Session not found")
+			return
+		}
+		response.InternalError(c, "Failed to revoke session")
+		return
+	}
+
+	response.SuccessWithMessage(c, nil, "Session revoked successfully")
+}
+
+// JWKS is served in the standard JWK Set format rather than the usual
+// response envelope, so that off-the-shelf JWT libraries can consume it.
+func (h *AuthHandler) JWKS(c *gin.Context) {
+	jwks, err := h.service.JWKS()
+	if err != nil {
+		response.InternalError(c, "Failed to get signing keys")
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, jwks)
+}
+
 func (h *AuthHandler) Me(c *gin.Context) {
 	user, exists := c.Get("user")
 	if !exists {
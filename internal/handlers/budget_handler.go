@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"mamonedz/internal/models"
+	"mamonedz/internal/services"
+	"mamonedz/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type BudgetHandler struct {
+	service  services.BudgetService
+	validate *validator.Validate
+}
+
+func NewBudgetHandler(service services.BudgetService) *BudgetHandler {
+	return &BudgetHandler{
+		service:  service,
+		validate: validator.New(),
+	}
+}
+
+func (h *BudgetHandler) Create(c *gin.Context) {
+	var req models.CreateBudgetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondInvalidBody(c, err)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		response.BadRequest(c, "Validation failed: "+err.Error())
+		return
+	}
+
+	budget, err := h.service.Create(getUserID(c), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidCategory):
+			response.BadRequest(c, "Invalid category")
+		case errors.Is(err, services.ErrBudgetExists):
+			response.Error(c, 409, "Budget already exists")
+		case errors.Is(err, services.ErrInvalidMonth):
+			response.BadRequest(c, "Invalid month format, use YYYY-MM")
+		default:
+			response.InternalError(c, "Failed to create budget")
+		}
+		return
+	}
+
+	response.Created(c, budget, "Budget created successfully")
+}
+
+func (h *BudgetHandler) GetAll(c *gin.Context) {
+	budgets, err := h.service.GetAll(getUserID(c))
+	if err != nil {
+		response.InternalError(c, "Failed to get budgets")
+		return
+	}
+
+	response.Success(c, budgets)
+}
+
+func (h *BudgetHandler) Update(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid budget ID")
+		return
+	}
+
+	var req models.UpdateBudgetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondInvalidBody(c, err)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		response.BadRequest(c, "Validation failed: "+err.Error())
+		return
+	}
+
+	budget, err := h.service.Update(id, getUserID(c), &req)
+	if err != nil {
+		if errors.Is(err, services.ErrBudgetNotFound) {
+			response.NotFound(c, "Budget not found")
+			return
+		}
+		response.InternalError(c, "Failed to update budget")
+		return
+	}
+
+	response.SuccessWithMessage(c, budget, "Budget updated successfully")
+}
+
+func (h *BudgetHandler) Delete(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid budget ID")
+		return
+	}
+
+	if err := h.service.Delete(id, getUserID(c)); err != nil {
+		if errors.Is(err, services.ErrBudgetNotFound) {
+			response.NotFound(c, "Budget not found")
+			return
+		}
+		response.InternalError(c, "Failed to delete budget")
+		return
+	}
+
+	response.SuccessWithMessage(c, nil, "Budget deleted successfully")
+}
+
+func (h *BudgetHandler) GetStatus(c *gin.Context) {
+	month := time.Now()
+	if value := c.Query("month"); value != "" {
+		var err error
+		if month, err = services.ParseMonth(value); err != nil {
+			response.BadRequest(c, "Invalid month format, use YYYY-MM")
+			return
+		}
+	}
+
+	statuses, err := h.service.GetStatus(getUserID(c), month)
+	if err != nil {
+		response.InternalError(c, "Failed to get budget status")
+		return
+	}
+
+	response.Success(c, statuses)
+}
+
+func (h *BudgetHandler) GetAlerts(c *gin.Context) {
+	limit, offset := 20, 0
+	if l, err := strconv.Atoi(c.Query("limit")); err == nil && l > 0 {
+		limit = l
+	}
+	if o, err := strconv.Atoi(c.Query("offset")); err == nil && o >= 0 {
+		offset = o
+	}
+
+	alerts, total, err := h.service.GetAlerts(getUserID(c), limit, offset)
+	if err != nil {
+		response.InternalError(c, "Failed to get budget alerts")
+		return
+	}
+
+	response.SuccessWithMeta(c, alerts, &response.Meta{
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	})
+}
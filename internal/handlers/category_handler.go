@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"errors"
+
+	"mamonedz/internal/models"
+	"mamonedz/internal/services"
+	"mamonedz/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type CategoryHandler struct {
+	service  services.CategoryService
+	validate *validator.Validate
+}
+
+func NewCategoryHandler(service services.CategoryService) *CategoryHandler {
+	return &CategoryHandler{
+		service:  service,
+		validate: validator.New(),
+	}
+}
+
+func (h *CategoryHandler) Create(c *gin.Context) {
+	var req models.CreateCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body")
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		response.BadRequest(c, "Validation failed: "+err.Error())
+		return
+	}
+
+	category, err := h.service.Create(getUserID(c), &req)
+	if err != nil {
+		if errors.Is(err, services.ErrCategoryExists) {
+			response.BadRequest(c, "Category already exists")
+			return
+		}
+		if errors.Is(err, services.ErrParentNotFound) {
+			response.BadRequest(c, "Parent category not found")
+			return
+		}
+		if errors.Is(err, services.ErrCategoryTypeMismatch) {
+			response.BadRequest(c, "Parent category must have the same type")
+			return
+		}
+		response.InternalError(c, "Failed to create category")
+		return
+	}
+
+	response.Created(c, category, "Category created successfully")
+}
+
+func (h *CategoryHandler) GetAll(c *gin.Context) {
+	categoryType := c.Query("type")
+	if categoryType != "" && categoryType != models.TransactionTypeExpense && categoryType != models.TransactionTypeIncome {
+		response.BadRequest(c, "type must be expense or income")
+		return
+	}
+
+	categories, err := h.service.GetAll(getUserID(c), categoryType)
+	if err != nil {
+		response.InternalError(c, "Failed to get categories")
+		return
+	}
+
+	response.Success(c, categories)
+}
+
+func (h *CategoryHandler) Update(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid category ID")
+		return
+	}
+
+	var req models.UpdateCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body")
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		response.BadRequest(c, "Validation failed: "+err.Error())
+		return
+	}
+
+	category, err := h.service.Update(id, getUserID(c), &req)
+	if err != nil {
+		if errors.Is(err, services.ErrCategoryNotFound) {
+			response.NotFound(c, "Category not found")
+			return
+		}
+		if errors.Is(err, services.ErrCategoryExists) {
+			response.BadRequest(c, "Category already exists")
+			return
+		}
+		if errors.Is(err, services.ErrParentNotFound) {
+			response.BadRequest(c, "Parent category not found")
+			return
+		}
+		if errors.Is(err, services.ErrInvalidParent) {
+			response.BadRequest(c, "A category cannot be moved under itself or its subcategories")
+			return
+		}
+		if errors.Is(err, services.ErrCategoryTypeMismatch) {
+			response.BadRequest(c, "Parent category must have the same type")
+			return
+		}
+		response.InternalError(c, "Failed to update category")
+		return
+	}
+
+	response.SuccessWithMessage(c, category, "Category updated successfully")
+}
+
+// Delete removes a category. If it still has expenses, the reassign_to query
+// parameter names the category they are moved to.
+func (h *CategoryHandler) Delete(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid category ID")
+		return
+	}
+
+	var reassignTo *uuid.UUID
+	if target := c.Query("reassign_to"); target != "" {
+		targetID, err := uuid.Parse(target)
+		if err != nil {
+			response.BadRequest(c, "Invalid reassign_to category ID")
+			return
+		}
+		reassignTo = &targetID
+	}
+
+	if err := h.service.Delete(id, getUserID(c), reassignTo); err != nil {
+		switch {
+		case errors.Is(err, services.ErrCategoryNotFound):
+			response.NotFound(c, "Category not found")
+		case errors.Is(err, services.ErrCategoryInUse):
+			response.Error(c, 409, "Category has expenses, pass reassign_to with the category to move them to")
+		case errors.Is(err, services.ErrInvalidReassignment):
+			response.BadRequest(c, "Expenses must be moved to a different category")
+		case errors.Is(err, services.ErrCategoryTypeMismatch):
+			response.BadRequest(c, "Expenses must be moved to a category of the same type")
+		default:
+			response.InternalError(c, "Failed to delete category")
+		}
+		return
+	}
+
+	response.SuccessWithMessage(c, nil, "Category deleted successfully")
+}
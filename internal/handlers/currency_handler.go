@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"mamonedz/internal/models"
+	"mamonedz/internal/services"
+	"mamonedz/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type CurrencyHandler struct {
+	service  services.CurrencyService
+	validate *validator.Validate
+}
+
+func NewCurrencyHandler(service services.CurrencyService) *CurrencyHandler {
+	return &CurrencyHandler{
+		service:  service,
+		validate: validator.New(),
+	}
+}
+
+func (h *CurrencyHandler) SetBaseCurrency(c *gin.Context) {
+	var req models.UpdateBaseCurrencyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body")
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		response.BadRequest(c, "Validation failed: "+err.Error())
+		return
+	}
+
+	user, err := h.service.SetBaseCurrency(getUserID(c), req.BaseCurrency)
+	if err != nil {
+		response.InternalError(c, "Failed to change base currency")
+		return
+	}
+
+	response.SuccessWithMessage(c, user, "Base currency changed successfully")
+}
+
+func (h *CurrencyHandler) GetRates(c *gin.Context) {
+	filter := &models.ExchangeRateFilter{
+		BaseCurrency:  c.Query("base"),
+		QuoteCurrency: c.Query("quote"),
+		Limit:         50,
+		Offset:        0,
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		if l, err := strconv.Atoi(limit); err == nil && l > 0 {
+			filter.Limit = l
+		}
+	}
+	if offset := c.Query("offset"); offset != "" {
+		if o, err := strconv.Atoi(offset); err == nil && o >= 0 {
+			filter.Offset = o
+		}
+	}
+
+	rates, total, err := h.service.GetRates(filter)
+	if err != nil {
+		response.InternalError(c, "Failed to get exchange rates")
+		return
+	}
+
+	response.SuccessWithMeta(c, rates, &response.Meta{
+		Total:  total,
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	})
+}
+
+func (h *CurrencyHandler) CreateRate(c *gin.Context) {
+	var req models.CreateExchangeRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body")
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		response.BadRequest(c, "Validation failed: "+err.Error())
+		return
+	}
+
+	rate, err := h.service.CreateRate(&req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidDate) {
+			response.BadRequest(c, "Invalid date format, use YYYY-MM-DD")
+			return
+		}
+		response.InternalError(c, "Failed to save exchange rate")
+		return
+	}
+
+	response.Created(c, rate, "Exchange rate saved successfully")
+}
+
+// ImportRates takes the CSV as the raw request body.
+func (h *CurrencyHandler) ImportRates(c *gin.Context) {
+	result, err := h.service.ImportRates(c.Request.Body)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidRatesCSV) {
+			response.BadRequest(c, err.Error())
+			return
+		}
+		response.InternalError(c, "Failed to import exchange rates")
+		return
+	}
+
+	response.SuccessWithMessage(c, result, "Exchange rates imported successfully")
+}
@@ -3,10 +3,12 @@ package handlers
 import (
 	"errors"
 	"strconv"
+	"strings"
 	"time"
 
 	"mamonedz/internal/models"
 	"mamonedz/internal/services"
+	"mamonedz/pkg/money"
 	"mamonedz/pkg/response"
 
 	"github.com/gin-gonic/gin"
@@ -20,14 +22,9 @@ type ExpenseHandler struct {
 }
 
 func NewExpenseHandler(service services.ExpenseService) *ExpenseHandler {
-	v := validator.New()
-	v.RegisterValidation("validcategory", func(fl validator.FieldLevel) bool {
-		return models.ValidCategories[fl.Field().String()]
-	})
-
 	return &ExpenseHandler{
 		service:  service,
-		validate: v,
+		validate: validator.New(),
 	}
 }
 
@@ -36,10 +33,35 @@ func getUserID(c *gin.Context) uuid.UUID {
 	return *userID.(*uuid.UUID)
 }
 
+// parseQueryID reads the optional ID in query parameter key. It writes the
+// error response and returns false when the ID is malformed.
+func parseQueryID(c *gin.Context, key string) (*uuid.UUID, bool) {
+	value := c.Query(key)
+	if value == "" {
+		return nil, true
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		response.BadRequest(c, "Invalid "+key)
+		return nil, false
+	}
+	return &id, true
+}
+
+// respondInvalidBody reports a request body that could not be decoded,
+// naming the problem when it is a malformed amount such as 0.001.
+func respondInvalidBody(c *gin.Context, err error) {
+	if errors.Is(err, money.ErrInvalid) || errors.Is(err, money.ErrTooPrecise) || errors.Is(err, money.ErrOutOfRange) {
+		response.BadRequest(c, "Validation failed: "+err.Error())
+		return
+	}
+	response.BadRequest(c, "Invalid request body")
+}
+
 func (h *ExpenseHandler) Create(c *gin.Context) {
 	var req models.CreateExpenseRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "Invalid request body")
+		respondInvalidBody(c, err)
 		return
 	}
 
@@ -55,10 +77,30 @@ func (h *ExpenseHandler) Create(c *gin.Context) {
 			response.BadRequest(c, "Invalid category")
 			return
 		}
+		if errors.Is(err, services.ErrInvalidAccount) {
+			response.BadRequest(c, "Invalid account")
+			return
+		}
+		if errors.Is(err, services.ErrAccountCurrency) {
+			response.BadRequest(c, "Currency does not match the account")
+			return
+		}
 		if errors.Is(err, services.ErrInvalidDate) {
 			response.BadRequest(c, "Invalid date format, use YYYY-MM-DD")
 			return
 		}
+		if errors.Is(err, services.ErrLedgerNotFound) {
+			response.BadRequest(c, "Invalid ledger")
+			return
+		}
+		if errors.Is(err, services.ErrLedgerForbidden) {
+			response.Error(c, 403, "Your role in this ledger does not allow changes")
+			return
+		}
+		if errors.Is(err, services.ErrInvalidPayer) {
+			response.BadRequest(c, "Payer must be a member of the ledger")
+			return
+		}
 		response.InternalError(c, "Failed to create expense")
 		return
 	}
@@ -92,10 +134,18 @@ func (h *ExpenseHandler) GetAll(c *gin.Context) {
 	userID := getUserID(c)
 	filter := &models.ExpenseFilter{
 		UserID: userID,
+		Type:   c.DefaultQuery("type", models.TransactionTypeExpense),
 		Limit:  10,
 		Offset: 0,
 	}
 
+	switch filter.Type {
+	case models.TransactionTypeExpense, models.TransactionTypeIncome, models.TransactionTypeAll:
+	default:
+		response.BadRequest(c, "type must be expense, income or all")
+		return
+	}
+
 	if startDate := c.Query("start_date"); startDate != "" {
 		if t, err := time.Parse("2006-01-02", startDate); err == nil {
 			filter.StartDate = &t
@@ -109,6 +159,27 @@ func (h *ExpenseHandler) GetAll(c *gin.Context) {
 	if category := c.Query("category"); category != "" {
 		filter.Category = &category
 	}
+	var ok bool
+	if filter.CategoryID, ok = parseQueryID(c, "category_id"); !ok {
+		return
+	}
+	if filter.LedgerID, ok = parseQueryID(c, "ledger_id"); !ok {
+		return
+	}
+	if filter.AccountID, ok = parseQueryID(c, "account_id"); !ok {
+		return
+	}
+	if filter.RecurringID, ok = parseQueryID(c, "recurring_id"); !ok {
+		return
+	}
+	if tags := c.Query("tags"); tags != "" {
+		filter.Tags = services.NormalizeTags(strings.Split(tags, ","))
+		filter.TagMatch = c.DefaultQuery("tag_match", models.TagMatchAny)
+		if filter.TagMatch != models.TagMatchAny && filter.TagMatch != models.TagMatchAll {
+			response.BadRequest(c, "tag_match must be any or all")
+			return
+		}
+	}
 	if limit := c.Query("limit"); limit != "" {
 		if l, err := strconv.Atoi(limit); err == nil && l > 0 {
 			filter.Limit = l
@@ -122,6 +193,10 @@ func (h *ExpenseHandler) GetAll(c *gin.Context) {
 
 	expenses, total, err := h.service.GetAll(filter)
 	if err != nil {
+		if errors.Is(err, services.ErrLedgerNotFound) {
+			response.BadRequest(c, "Invalid ledger")
+			return
+		}
 		response.InternalError(c, "Failed to get expenses")
 		return
 	}
@@ -143,7 +218,7 @@ func (h *ExpenseHandler) Update(c *gin.Context) {
 
 	var req models.UpdateExpenseRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "Invalid request body")
+		respondInvalidBody(c, err)
 		return
 	}
 
@@ -163,10 +238,26 @@ func (h *ExpenseHandler) Update(c *gin.Context) {
 			response.BadRequest(c, "Invalid category")
 			return
 		}
+		if errors.Is(err, services.ErrInvalidAccount) {
+			response.BadRequest(c, "Invalid account")
+			return
+		}
+		if errors.Is(err, services.ErrAccountCurrency) {
+			response.BadRequest(c, "Currency does not match the account")
+			return
+		}
 		if errors.Is(err, services.ErrInvalidDate) {
 			response.BadRequest(c, "Invalid date format, use YYYY-MM-DD")
 			return
 		}
+		if errors.Is(err, services.ErrLedgerForbidden) {
+			response.Error(c, 403, "Your role in this ledger does not allow changes")
+			return
+		}
+		if errors.Is(err, services.ErrInvalidPayer) {
+			response.BadRequest(c, "Payer must be a member of the ledger")
+			return
+		}
 		response.InternalError(c, "Failed to update expense")
 		return
 	}
@@ -188,6 +279,10 @@ func (h *ExpenseHandler) Delete(c *gin.Context) {
 			response.NotFound(c, "Expense not found")
 			return
 		}
+		if errors.Is(err, services.ErrLedgerForbidden) {
+			response.Error(c, 403, "Your role in this ledger does not allow changes")
+			return
+		}
 		response.InternalError(c, "Failed to delete expense")
 		return
 	}
@@ -196,11 +291,31 @@ func (h *ExpenseHandler) Delete(c *gin.Context) {
 }
 
 func (h *ExpenseHandler) GetStats(c *gin.Context) {
-	period := c.DefaultQuery("period", "month")
+	query := &models.StatsQuery{
+		Period:  c.DefaultQuery("period", "month"),
+		Type:    c.DefaultQuery("type", models.TransactionTypeExpense),
+		GroupBy: c.Query("group_by"),
+	}
+	if query.Type != models.TransactionTypeExpense && query.Type != models.TransactionTypeIncome {
+		response.BadRequest(c, "type must be expense or income")
+		return
+	}
+	if query.GroupBy != "" && query.GroupBy != "tag" {
+		response.BadRequest(c, "group_by must be tag")
+		return
+	}
+	var ok bool
+	if query.LedgerID, ok = parseQueryID(c, "ledger_id"); !ok {
+		return
+	}
 	userID := getUserID(c)
 
-	stats, err := h.service.GetStats(userID, period)
+	stats, err := h.service.GetStats(userID, query)
 	if err != nil {
+		if errors.Is(err, services.ErrLedgerNotFound) {
+			response.BadRequest(c, "Invalid ledger")
+			return
+		}
 		response.InternalError(c, "Failed to get statistics")
 		return
 	}
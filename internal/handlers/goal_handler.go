@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"mamonedz/internal/models"
+	"mamonedz/internal/services"
+	"mamonedz/pkg/money"
+	"mamonedz/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type GoalHandler struct {
+	service  services.GoalService
+	validate *validator.Validate
+}
+
+func NewGoalHandler(service services.GoalService) *GoalHandler {
+	return &GoalHandler{
+		service:  service,
+		validate: validator.New(),
+	}
+}
+
+func (h *GoalHandler) Create(c *gin.Context) {
+	var req models.CreateGoalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondInvalidBody(c, err)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		response.BadRequest(c, "Validation failed: "+err.Error())
+		return
+	}
+
+	goal, err := h.service.Create(getUserID(c), &req)
+	if err != nil {
+		respondGoalError(c, err, "Failed to create goal")
+		return
+	}
+
+	response.Created(c, goal, "Goal created successfully")
+}
+
+func (h *GoalHandler) GetAll(c *gin.Context) {
+	goals, err := h.service.GetAll(getUserID(c))
+	if err != nil {
+		response.InternalError(c, "Failed to get goals")
+		return
+	}
+
+	response.Success(c, goals)
+}
+
+func (h *GoalHandler) GetByID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid goal ID")
+		return
+	}
+
+	goal, err := h.service.GetByID(id, getUserID(c))
+	if err != nil {
+		respondGoalError(c, err, "Failed to get goal")
+		return
+	}
+
+	response.Success(c, goal)
+}
+
+func (h *GoalHandler) Update(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid goal ID")
+		return
+	}
+
+	var req models.UpdateGoalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondInvalidBody(c, err)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		response.BadRequest(c, "Validation failed: "+err.Error())
+		return
+	}
+
+	goal, err := h.service.Update(id, getUserID(c), &req)
+	if err != nil {
+		respondGoalError(c, err, "Failed to update goal")
+		return
+	}
+
+	response.SuccessWithMessage(c, goal, "Goal updated successfully")
+}
+
+func (h *GoalHandler) Delete(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid goal ID")
+		return
+	}
+
+	if err := h.service.Delete(id, getUserID(c)); err != nil {
+		respondGoalError(c, err, "Failed to delete goal")
+		return
+	}
+
+	response.SuccessWithMessage(c, nil, "Goal deleted successfully")
+}
+
+func (h *GoalHandler) AddContribution(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid goal ID")
+		return
+	}
+
+	var req models.CreateContributionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondInvalidBody(c, err)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		response.BadRequest(c, "Validation failed: "+err.Error())
+		return
+	}
+
+	contribution, err := h.service.AddContribution(id, getUserID(c), &req)
+	if err != nil {
+		respondGoalError(c, err, "Failed to add contribution")
+		return
+	}
+
+	response.Created(c, contribution, "Contribution added successfully")
+}
+
+func (h *GoalHandler) GetContributions(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid goal ID")
+		return
+	}
+
+	limit, offset := 20, 0
+	if l, err := strconv.Atoi(c.Query("limit")); err == nil && l > 0 {
+		limit = l
+	}
+	if o, err := strconv.Atoi(c.Query("offset")); err == nil && o >= 0 {
+		offset = o
+	}
+
+	contributions, total, err := h.service.GetContributions(id, getUserID(c), limit, offset)
+	if err != nil {
+		respondGoalError(c, err, "Failed to get contributions")
+		return
+	}
+
+	response.SuccessWithMeta(c, contributions, &response.Meta{
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	})
+}
+
+func (h *GoalHandler) DeleteContribution(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid goal ID")
+		return
+	}
+	contributionID, err := uuid.Parse(c.Param("contribution_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid contribution ID")
+		return
+	}
+
+	if err := h.service.DeleteContribution(contributionID, id, getUserID(c)); err != nil {
+		respondGoalError(c, err, "Failed to delete contribution")
+		return
+	}
+
+	response.SuccessWithMessage(c, nil, "Contribution deleted successfully")
+}
+
+// GetProjection projects the goal from the monthly query parameter, or from
+// the average contribution when it is not given.
+func (h *GoalHandler) GetProjection(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid goal ID")
+		return
+	}
+
+	var monthly *money.Amount
+	if value := c.Query("monthly"); value != "" {
+		amount, err := money.Parse(value)
+		if err != nil || amount <= 0 {
+			response.BadRequest(c, "Invalid monthly amount")
+			return
+		}
+		monthly = &amount
+	}
+
+	projection, err := h.service.GetProjection(id, getUserID(c), monthly)
+	if err != nil {
+		respondGoalError(c, err, "Failed to get goal projection")
+		return
+	}
+
+	response.Success(c, projection)
+}
+
+func respondGoalError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrGoalNotFound):
+		response.NotFound(c, "Goal not found")
+	case errors.Is(err, services.ErrContributionNotFound):
+		response.NotFound(c, "Contribution not found")
+	case errors.Is(err, services.ErrInvalidAccount):
+		response.BadRequest(c, "Invalid account")
+	case errors.Is(err, services.ErrAccountCurrency):
+		response.BadRequest(c, "Currency does not match the account")
+	case errors.Is(err, services.ErrInvalidTransfer):
+		response.BadRequest(c, "Invalid transfer")
+	case errors.Is(err, services.ErrTransferLinked):
+		response.Error(c, 409, "Transfer already recorded as a contribution")
+	case errors.Is(err, services.ErrContributionExceeds):
+		response.BadRequest(c, "Contribution cannot be larger than its transfer")
+	case errors.Is(err, services.ErrInvalidDate):
+		response.BadRequest(c, "Invalid date format, use YYYY-MM-DD")
+	default:
+		response.InternalError(c, fallback)
+	}
+}
@@ -0,0 +1,313 @@
+package handlers
+
+import (
+	"errors"
+
+	"mamonedz/internal/models"
+	"mamonedz/internal/services"
+	"mamonedz/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type LedgerHandler struct {
+	service  services.LedgerService
+	validate *validator.Validate
+}
+
+func NewLedgerHandler(service services.LedgerService) *LedgerHandler {
+	return &LedgerHandler{
+		service:  service,
+		validate: validator.New(),
+	}
+}
+
+func (h *LedgerHandler) Create(c *gin.Context) {
+	var req models.CreateLedgerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondInvalidBody(c, err)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		response.BadRequest(c, "Validation failed: "+err.Error())
+		return
+	}
+
+	ledger, err := h.service.Create(getUserID(c), &req)
+	if err != nil {
+		response.InternalError(c, "Failed to create ledger")
+		return
+	}
+
+	response.Created(c, ledger, "Ledger created successfully")
+}
+
+func (h *LedgerHandler) GetAll(c *gin.Context) {
+	ledgers, err := h.service.GetAll(getUserID(c))
+	if err != nil {
+		response.InternalError(c, "Failed to get ledgers")
+		return
+	}
+
+	response.Success(c, ledgers)
+}
+
+func (h *LedgerHandler) GetByID(c *gin.Context) {
+	id, ok := parseLedgerID(c)
+	if !ok {
+		return
+	}
+
+	ledger, err := h.service.GetByID(id, getUserID(c))
+	if err != nil {
+		respondLedgerError(c, err, "Failed to get ledger")
+		return
+	}
+
+	response.Success(c, ledger)
+}
+
+func (h *LedgerHandler) Update(c *gin.Context) {
+	id, ok := parseLedgerID(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateLedgerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondInvalidBody(c, err)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		response.BadRequest(c, "Validation failed: "+err.Error())
+		return
+	}
+
+	ledger, err := h.service.Update(id, getUserID(c), &req)
+	if err != nil {
+		respondLedgerError(c, err, "Failed to update ledger")
+		return
+	}
+
+	response.SuccessWithMessage(c, ledger, "Ledger updated successfully")
+}
+
+func (h *LedgerHandler) Delete(c *gin.Context) {
+	id, ok := parseLedgerID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(id, getUserID(c)); err != nil {
+		respondLedgerError(c, err, "Failed to delete ledger")
+		return
+	}
+
+	response.SuccessWithMessage(c, nil, "Ledger deleted successfully")
+}
+
+func (h *LedgerHandler) GetMembers(c *gin.Context) {
+	id, ok := parseLedgerID(c)
+	if !ok {
+		return
+	}
+
+	members, err := h.service.GetMembers(id, getUserID(c))
+	if err != nil {
+		respondLedgerError(c, err, "Failed to get members")
+		return
+	}
+
+	response.Success(c, members)
+}
+
+func (h *LedgerHandler) UpdateMember(c *gin.Context) {
+	id, ok := parseLedgerID(c)
+	if !ok {
+		return
+	}
+	memberID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid user ID")
+		return
+	}
+
+	var req models.UpdateMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondInvalidBody(c, err)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		response.BadRequest(c, "Validation failed: "+err.Error())
+		return
+	}
+
+	if err := h.service.UpdateMember(id, getUserID(c), memberID, &req); err != nil {
+		respondLedgerError(c, err, "Failed to update member")
+		return
+	}
+
+	response.SuccessWithMessage(c, nil, "Member updated successfully")
+}
+
+// RemoveMember removes a member, or lets the user leave when user_id is
+// their own.
+func (h *LedgerHandler) RemoveMember(c *gin.Context) {
+	id, ok := parseLedgerID(c)
+	if !ok {
+		return
+	}
+	memberID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid user ID")
+		return
+	}
+
+	if err := h.service.RemoveMember(id, getUserID(c), memberID); err != nil {
+		respondLedgerError(c, err, "Failed to remove member")
+		return
+	}
+
+	response.SuccessWithMessage(c, nil, "Member removed successfully")
+}
+
+func (h *LedgerHandler) Invite(c *gin.Context) {
+	id, ok := parseLedgerID(c)
+	if !ok {
+		return
+	}
+
+	var req models.InviteMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondInvalidBody(c, err)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		response.BadRequest(c, "Validation failed: "+err.Error())
+		return
+	}
+
+	invitation, err := h.service.Invite(id, getUserID(c), &req)
+	if err != nil {
+		respondLedgerError(c, err, "Failed to send invitation")
+		return
+	}
+
+	response.Created(c, invitation, "Invitation sent successfully")
+}
+
+func (h *LedgerHandler) GetInvitations(c *gin.Context) {
+	id, ok := parseLedgerID(c)
+	if !ok {
+		return
+	}
+
+	invitations, err := h.service.GetInvitations(id, getUserID(c))
+	if err != nil {
+		respondLedgerError(c, err, "Failed to get invitations")
+		return
+	}
+
+	response.Success(c, invitations)
+}
+
+func (h *LedgerHandler) RevokeInvitation(c *gin.Context) {
+	id, ok := parseLedgerID(c)
+	if !ok {
+		return
+	}
+	invitationID, err := uuid.Parse(c.Param("invitation_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid invitation ID")
+		return
+	}
+
+	if err := h.service.RevokeInvitation(id, getUserID(c), invitationID); err != nil {
+		respondLedgerError(c, err, "Failed to revoke invitation")
+		return
+	}
+
+	response.SuccessWithMessage(c, nil, "Invitation revoked successfully")
+}
+
+// GetMyInvitations lists the pending invitations sent to the user's email.
+func (h *LedgerHandler) GetMyInvitations(c *gin.Context) {
+	invitations, err := h.service.GetMyInvitations(getUserID(c))
+	if err != nil {
+		respondLedgerError(c, err, "Failed to get invitations")
+		return
+	}
+
+	response.Success(c, invitations)
+}
+
+func (h *LedgerHandler) AcceptInvitation(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid invitation ID")
+		return
+	}
+
+	ledger, err := h.service.AcceptInvitation(id, getUserID(c))
+	if err != nil {
+		respondLedgerError(c, err, "Failed to accept invitation")
+		return
+	}
+
+	response.SuccessWithMessage(c, ledger, "Invitation accepted successfully")
+}
+
+func (h *LedgerHandler) DeclineInvitation(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid invitation ID")
+		return
+	}
+
+	if err := h.service.DeclineInvitation(id, getUserID(c)); err != nil {
+		respondLedgerError(c, err, "Failed to decline invitation")
+		return
+	}
+
+	response.SuccessWithMessage(c, nil, "Invitation declined successfully")
+}
+
+func parseLedgerID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid ledger ID")
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func respondLedgerError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrLedgerNotFound):
+		response.NotFound(c, "Ledger not found")
+	case errors.Is(err, services.ErrLedgerForbidden):
+		response.Error(c, 403, "Your role in this ledger does not allow this")
+	case errors.Is(err, services.ErrPersonalLedger):
+		response.BadRequest(c, "Personal ledgers cannot be shared, deleted or change currency")
+	case errors.Is(err, services.ErrLedgerInUse):
+		response.Error(c, 409, "Ledger still has transactions")
+	case errors.Is(err, services.ErrLedgerOwner):
+		response.BadRequest(c, "The ledger owner cannot leave or change role")
+	case errors.Is(err, services.ErrAlreadyMember):
+		response.Error(c, 409, "User is already a member")
+	case errors.Is(err, services.ErrMemberNotFound):
+		response.NotFound(c, "Member not found")
+	case errors.Is(err, services.ErrInvitationNotFound):
+		response.NotFound(c, "Invitation not found")
+	case errors.Is(err, services.ErrInviteeUnverified):
+		response.Error(c, 403, "Email address not verified")
+	default:
+		response.InternalError(c, fallback)
+	}
+}
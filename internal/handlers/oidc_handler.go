@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"errors"
+
+	"mamonedz/internal/models"
+	"mamonedz/internal/services"
+	"mamonedz/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type OIDCHandler struct {
+	service  services.OIDCService
+	validate *validator.Validate
+}
+
+func NewOIDCHandler(service services.OIDCService) *OIDCHandler {
+	return &OIDCHandler{
+		service:  service,
+		validate: validator.New(),
+	}
+}
+
+func (h *OIDCHandler) Providers(c *gin.Context) {
+	providers := h.service.Providers()
+	if providers == nil {
+		providers = []string{}
+	}
+	response.Success(c, providers)
+}
+
+func (h *OIDCHandler) Login(c *gin.Context) {
+	result, err := h.service.AuthorizationURL(c.Request.Context(), c.Param("provider"))
+	if err != nil {
+		if errors.Is(err, services.ErrUnknownProvider) {
+			response.NotFound(c, "Identity provider not found")
+			return
+		}
+		response.InternalError(c, "Failed to start login")
+		return
+	}
+
+	response.Success(c, result)
+}
+
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	var req models.OIDCCallbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body")
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		response.BadRequest(c, "Validation failed: "+err.Error())
+		return
+	}
+
+	result, err := h.service.Callback(c.Request.Context(), c.Param("provider"), &req, clientInfo(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrUnknownProvider):
+			response.NotFound(c, "Identity provider not found")
+		case errors.Is(err, services.ErrInvalidOIDCState):
+			response.BadRequest(c, "Invalid or expired login state")
+		case errors.Is(err, services.ErrOIDCEmailRequired):
+			response.Error(c, 401, "Identity provider did not return a verified email")
+		case errors.Is(err, services.ErrOIDCEmailTaken):
+			response.Error(c, 409, "An unverified account already uses this email; verify it or sign in with its password first")
+		case errors.Is(err, services.ErrOIDCLoginFailed):
+			response.Error(c, 401, "Identity provider login failed")
+		case errors.Is(err, services.ErrAccountDisabled):
+			response.Error(c, 403, "Account disabled")
+		default:
+			response.InternalError(c, "Failed to login")
+		}
+		return
+	}
+
+	if result.MFARequired {
+		response.SuccessWithMessage(c, result, "Two-factor authentication required")
+		return
+	}
+
+	response.SuccessWithMessage(c, result, "Login successful")
+}
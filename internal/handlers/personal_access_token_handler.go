@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"errors"
+
+	"mamonedz/internal/models"
+	"mamonedz/internal/services"
+	"mamonedz/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type PersonalAccessTokenHandler struct {
+	service  services.PersonalAccessTokenService
+	validate *validator.Validate
+}
+
+func NewPersonalAccessTokenHandler(service services.PersonalAccessTokenService) *PersonalAccessTokenHandler {
+	v := validator.New()
+	v.RegisterValidation("validscope", func(fl validator.FieldLevel) bool {
+		return models.ValidScopes[fl.Field().String()]
+	})
+
+	return &PersonalAccessTokenHandler{
+		service:  service,
+		validate: v,
+	}
+}
+
+func (h *PersonalAccessTokenHandler) Create(c *gin.Context) {
+	var req models.CreatePersonalAccessTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body")
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		response.BadRequest(c, "Validation failed: "+err.Error())
+		return
+	}
+
+	result, err := h.service.Create(getUserID(c), &req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidScope) {
+			response.BadRequest(c, "Invalid scope")
+			return
+		}
+		response.InternalError(c, "Failed to create personal access token")
+		return
+	}
+
+	response.Created(c, result, "Personal access token created. Copy it now, it will not be shown again")
+}
+
+func (h *PersonalAccessTokenHandler) GetAll(c *gin.Context) {
+	tokens, err := h.service.GetAll(getUserID(c))
+	if err != nil {
+		response.InternalError(c, "Failed to get personal access tokens")
+		return
+	}
+
+	response.Success(c, tokens)
+}
+
+func (h *PersonalAccessTokenHandler) Revoke(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid token ID")
+		return
+	}
+
+	if err := h.service.Revoke(id, getUserID(c)); err != nil {
+		if errors.Is(err, services.ErrAccessTokenNotFound) {
+			response.NotFound(c, "Personal access token not found")
+			return
+		}
+		response.InternalError(c, "Failed to revoke personal access token")
+		return
+	}
+
+	response.SuccessWithMessage(c, nil, "Personal access token revoked successfully")
+}
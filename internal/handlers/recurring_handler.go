@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"errors"
+
+	"mamonedz/internal/models"
+	"mamonedz/internal/services"
+	"mamonedz/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type RecurringHandler struct {
+	service  services.RecurringService
+	validate *validator.Validate
+}
+
+func NewRecurringHandler(service services.RecurringService) *RecurringHandler {
+	return &RecurringHandler{
+		service:  service,
+		validate: validator.New(),
+	}
+}
+
+func (h *RecurringHandler) Create(c *gin.Context) {
+	var req models.CreateRecurringExpenseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondInvalidBody(c, err)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		response.BadRequest(c, "Validation failed: "+err.Error())
+		return
+	}
+
+	template, err := h.service.Create(getUserID(c), &req)
+	if err != nil {
+		respondRecurringError(c, err, "Failed to create recurring expense")
+		return
+	}
+
+	response.Created(c, template, "Recurring expense created successfully")
+}
+
+func (h *RecurringHandler) GetAll(c *gin.Context) {
+	templates, err := h.service.GetAll(getUserID(c))
+	if err != nil {
+		response.InternalError(c, "Failed to get recurring expenses")
+		return
+	}
+
+	response.Success(c, templates)
+}
+
+func (h *RecurringHandler) GetByID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid recurring expense ID")
+		return
+	}
+
+	template, err := h.service.GetByID(id, getUserID(c))
+	if err != nil {
+		respondRecurringError(c, err, "Failed to get recurring expense")
+		return
+	}
+
+	response.Success(c, template)
+}
+
+func (h *RecurringHandler) Update(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid recurring expense ID")
+		return
+	}
+
+	var req models.UpdateRecurringExpenseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondInvalidBody(c, err)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		response.BadRequest(c, "Validation failed: "+err.Error())
+		return
+	}
+
+	template, err := h.service.Update(id, getUserID(c), &req)
+	if err != nil {
+		respondRecurringError(c, err, "Failed to update recurring expense")
+		return
+	}
+
+	response.SuccessWithMessage(c, template, "Recurring expense updated successfully")
+}
+
+func (h *RecurringHandler) Delete(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid recurring expense ID")
+		return
+	}
+
+	if err := h.service.Delete(id, getUserID(c)); err != nil {
+		respondRecurringError(c, err, "Failed to delete recurring expense")
+		return
+	}
+
+	response.SuccessWithMessage(c, nil, "Recurring expense deleted successfully")
+}
+
+func (h *RecurringHandler) Skip(c *gin.Context) {
+	h.changeState(c, h.service.Skip, "Next occurrence skipped")
+}
+
+func (h *RecurringHandler) Pause(c *gin.Context) {
+	h.changeState(c, h.service.Pause, "Recurring expense paused")
+}
+
+func (h *RecurringHandler) Resume(c *gin.Context) {
+	h.changeState(c, h.service.Resume, "Recurring expense resumed")
+}
+
+func (h *RecurringHandler) changeState(c *gin.Context, change func(id, userID uuid.UUID) (*models.RecurringExpense, error), message string) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid recurring expense ID")
+		return
+	}
+
+	template, err := change(id, getUserID(c))
+	if err != nil {
+		respondRecurringError(c, err, "Failed to update recurring expense")
+		return
+	}
+
+	response.SuccessWithMessage(c, template, message)
+}
+
+func respondRecurringError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrRecurringNotFound):
+		response.NotFound(c, "Recurring expense not found")
+	case errors.Is(err, services.ErrRecurringFinished):
+		response.BadRequest(c, "Recurring expense has no further occurrences")
+	case errors.Is(err, services.ErrRecurringConflict):
+		response.Error(c, 409, "Recurring expense was changed at the same time, try again")
+	case errors.Is(err, services.ErrInvalidSchedule):
+		response.BadRequest(c, "End date is before start date")
+	case errors.Is(err, services.ErrInvalidCategory):
+		response.BadRequest(c, "Invalid category")
+	case errors.Is(err, services.ErrInvalidAccount):
+		response.BadRequest(c, "Invalid account")
+	case errors.Is(err, services.ErrAccountCurrency):
+		response.BadRequest(c, "Currency does not match the account")
+	case errors.Is(err, services.ErrInvalidDate):
+		response.BadRequest(c, "Invalid date format, use YYYY-MM-DD")
+	default:
+		response.InternalError(c, fallback)
+	}
+}
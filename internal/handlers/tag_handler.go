@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"errors"
+
+	"mamonedz/internal/models"
+	"mamonedz/internal/services"
+	"mamonedz/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type TagHandler struct {
+	service  services.TagService
+	validate *validator.Validate
+}
+
+func NewTagHandler(service services.TagService) *TagHandler {
+	return &TagHandler{
+		service:  service,
+		validate: validator.New(),
+	}
+}
+
+func (h *TagHandler) Create(c *gin.Context) {
+	var req models.CreateTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body")
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		response.BadRequest(c, "Validation failed: "+err.Error())
+		return
+	}
+
+	tag, err := h.service.Create(getUserID(c), &req)
+	if err != nil {
+		if errors.Is(err, services.ErrEmptyTagName) {
+			response.BadRequest(c, "Tag name cannot be blank")
+			return
+		}
+		if errors.Is(err, services.ErrTagExists) {
+			response.BadRequest(c, "Tag already exists")
+			return
+		}
+		response.InternalError(c, "Failed to create tag")
+		return
+	}
+
+	response.Created(c, tag, "Tag created successfully")
+}
+
+func (h *TagHandler) GetAll(c *gin.Context) {
+	tags, err := h.service.GetAll(getUserID(c))
+	if err != nil {
+		response.InternalError(c, "Failed to get tags")
+		return
+	}
+
+	response.Success(c, tags)
+}
+
+func (h *TagHandler) Rename(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid tag ID")
+		return
+	}
+
+	var req models.RenameTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body")
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		response.BadRequest(c, "Validation failed: "+err.Error())
+		return
+	}
+
+	tag, err := h.service.Rename(id, getUserID(c), &req)
+	if err != nil {
+		if errors.Is(err, services.ErrTagNotFound) {
+			response.NotFound(c, "Tag not found")
+			return
+		}
+		if errors.Is(err, services.ErrEmptyTagName) {
+			response.BadRequest(c, "Tag name cannot be blank")
+			return
+		}
+		if errors.Is(err, services.ErrTagExists) {
+			response.BadRequest(c, "Tag already exists, merge the tags instead")
+			return
+		}
+		response.InternalError(c, "Failed to rename tag")
+		return
+	}
+
+	response.SuccessWithMessage(c, tag, "Tag renamed successfully")
+}
+
+func (h *TagHandler) Merge(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid tag ID")
+		return
+	}
+
+	var req models.MergeTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body")
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		response.BadRequest(c, "Validation failed: "+err.Error())
+		return
+	}
+
+	tag, err := h.service.Merge(id, getUserID(c), &req)
+	if err != nil {
+		if errors.Is(err, services.ErrTagNotFound) {
+			response.NotFound(c, "Tag not found")
+			return
+		}
+		if errors.Is(err, services.ErrInvalidMerge) {
+			response.BadRequest(c, "A tag cannot be merged into itself")
+			return
+		}
+		response.InternalError(c, "Failed to merge tags")
+		return
+	}
+
+	response.SuccessWithMessage(c, tag, "Tags merged successfully")
+}
+
+func (h *TagHandler) Delete(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid tag ID")
+		return
+	}
+
+	if err := h.service.Delete(id, getUserID(c)); err != nil {
+		if errors.Is(err, services.ErrTagNotFound) {
+			response.NotFound(c, "Tag not found")
+			return
+		}
+		response.InternalError(c, "Failed to delete tag")
+		return
+	}
+
+	response.SuccessWithMessage(c, nil, "Tag deleted successfully")
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"mamonedz/internal/models"
+	"mamonedz/internal/services"
+	"mamonedz/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type TransferHandler struct {
+	service  services.TransferService
+	validate *validator.Validate
+}
+
+func NewTransferHandler(service services.TransferService) *TransferHandler {
+	return &TransferHandler{
+		service:  service,
+		validate: validator.New(),
+	}
+}
+
+func (h *TransferHandler) Create(c *gin.Context) {
+	var req models.CreateTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondInvalidBody(c, err)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		response.BadRequest(c, "Validation failed: "+err.Error())
+		return
+	}
+
+	transfer, err := h.service.Create(getUserID(c), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidAccount):
+			response.BadRequest(c, "Invalid account")
+		case errors.Is(err, services.ErrCurrencyMismatch):
+			response.BadRequest(c, "Accounts use different currencies")
+		case errors.Is(err, services.ErrInvalidCategory):
+			response.BadRequest(c, "Invalid fee category")
+		case errors.Is(err, services.ErrInvalidDate):
+			response.BadRequest(c, "Invalid date format, use YYYY-MM-DD")
+		default:
+			response.InternalError(c, "Failed to create transfer")
+		}
+		return
+	}
+
+	response.Created(c, transfer, "Transfer created successfully")
+}
+
+func (h *TransferHandler) GetByID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid transfer ID")
+		return
+	}
+
+	transfer, err := h.service.GetByID(id, getUserID(c))
+	if err != nil {
+		if errors.Is(err, services.ErrTransferNotFound) {
+			response.NotFound(c, "Transfer not found")
+			return
+		}
+		response.InternalError(c, "Failed to get transfer")
+		return
+	}
+
+	response.Success(c, transfer)
+}
+
+func (h *TransferHandler) GetAll(c *gin.Context) {
+	filter := &models.TransferFilter{
+		UserID: getUserID(c),
+		Limit:  10,
+		Offset: 0,
+	}
+
+	var ok bool
+	if filter.AccountID, ok = parseQueryID(c, "account_id"); !ok {
+		return
+	}
+	if limit := c.Query("limit"); limit != "" {
+		if l, err := strconv.Atoi(limit); err == nil && l > 0 {
+			filter.Limit = l
+		}
+	}
+	if offset := c.Query("offset"); offset != "" {
+		if o, err := strconv.Atoi(offset); err == nil && o >= 0 {
+			filter.Offset = o
+		}
+	}
+
+	transfers, total, err := h.service.GetAll(filter)
+	if err != nil {
+		response.InternalError(c, "Failed to get transfers")
+		return
+	}
+
+	response.SuccessWithMeta(c, transfers, &response.Meta{
+		Total:  total,
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	})
+}
+
+func (h *TransferHandler) Delete(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid transfer ID")
+		return
+	}
+
+	if err := h.service.Delete(id, getUserID(c)); err != nil {
+		if errors.Is(err, services.ErrTransferNotFound) {
+			response.NotFound(c, "Transfer not found")
+			return
+		}
+		response.InternalError(c, "Failed to delete transfer")
+		return
+	}
+
+	response.SuccessWithMessage(c, nil, "Transfer deleted successfully")
+}
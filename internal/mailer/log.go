@@ -0,0 +1,42 @@
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// logMailer writes messages to the application log, and additionally appends
+// them to a file when a path is configured. It is meant for local development
+// and tests where no real mail server is available.
+type logMailer struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewLogMailer(path string) Mailer {
+	return &logMailer{path: path}
+}
+
+func (m *logMailer) Send(msg *Message) error {
+	log.Printf("[MAIL] To: %s | Subject: %s\n%s", msg.To, msg.Subject, msg.Body)
+
+	if m.path == "" {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, err := os.OpenFile(m.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open mail file: %w", err)
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintf(f, "Date: %s\nTo: %s\nSubject: %s\n\n%s\n\n---\n",
+		time.Now().Format(time.RFC1123Z), msg.To, msg.Subject, msg.Body)
+	return err
+}
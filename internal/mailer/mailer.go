@@ -0,0 +1,30 @@
+package mailer
+
+import (
+	"fmt"
+
+	"mamonedz/internal/config"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers transactional emails such as password reset links.
+type Mailer interface {
+	Send(msg *Message) error
+}
+
+// New returns the Mailer selected by cfg.MailDriver.
+func New(cfg *config.Config) (Mailer, error) {
+	switch cfg.MailDriver {
+	case "smtp":
+		return NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.MailFrom), nil
+	case "log", "":
+		return NewLogMailer(cfg.MailFilePath), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver: %s", cfg.MailDriver)
+	}
+}
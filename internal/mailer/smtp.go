@@ -0,0 +1,41 @@
+package mailer
+
+import (
+	"fmt"
+	"net/smtp"
+	"strings"
+)
+
+type smtpMailer struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+func NewSMTPMailer(host, port, username, password, from string) Mailer {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &smtpMailer{
+		addr: host + ":" + port,
+		auth: auth,
+		from: from,
+	}
+}
+
+func (m *smtpMailer) Send(msg *Message) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", m.from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=\"UTF-8\"\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(msg.Body)
+
+	if err := smtp.SendMail(m.addr, m.auth, m.from, []string{msg.To}, []byte(b.String())); err != nil {
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+	return nil
+}
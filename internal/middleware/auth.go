@@ -1,8 +1,10 @@
 package middleware
 
 import (
+	"errors"
 	"strings"
 
+	"mamonedz/internal/models"
 	"mamonedz/internal/services"
 	"mamonedz/pkg/response"
 
@@ -26,22 +28,95 @@ func Auth(authService services.AuthService) gin.HandlerFunc {
 		}
 
 		tokenString := parts[1]
-		userID, err := authService.ValidateToken(tokenString)
+		user, claims, err := authService.Authenticate(tokenString)
 		if err != nil {
-			response.Error(c, 401, "Invalid or expired token")
+			if errors.Is(err, services.ErrUserNotFound) {
+				response.Error(c, 401, "User not found")
+			} else if errors.Is(err, services.ErrAccountDisabled) {
+				response.Error(c, 403, "Account disabled")
+			} else {
+				response.Error(c, 401, "Invalid or expired token")
+			}
 			c.Abort()
 			return
 		}
 
-		user, err := authService.GetUserByID(*userID)
-		if err != nil {
-			response.Error(c, 401, "User not found")
+		client := &models.ClientInfo{IP: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+		if err := authService.TouchSession(claims, client); err != nil {
+			response.Error(c, 401, "Invalid or expired token")
 			c.Abort()
 			return
 		}
 
-		c.Set("user_id", userID)
+		c.Set("user_id", &user.ID)
 		c.Set("user", user)
+		c.Set("token_claims", claims)
+		c.Next()
+	}
+}
+
+// RequireVerifiedEmail rejects users who have not confirmed their email
+// address. It must run after Auth and is a no-op when enabled is false.
+func RequireVerifiedEmail(enabled bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !enabled {
+			c.Next()
+			return
+		}
+
+		user, _ := c.Get("user")
+		if u, ok := user.(*models.User); !ok || u.EmailVerifiedAt == nil {
+			response.Error(c, 403, "Email address not verified")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireScope allows the request when the token grants scope. Session
+// tokens carry every scope; personal access tokens only those they were
+// created with.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, _ := c.Get("token_claims")
+		if tc, ok := claims.(*services.TokenClaims); !ok || !tc.HasScope(scope) {
+			response.Error(c, 403, "Token is missing required scope: "+scope)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireSession rejects personal access tokens, for account management
+// routes that scripts should never reach.
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, _ := c.Get("token_claims")
+		if tc, ok := claims.(*services.TokenClaims); !ok || tc.IsPersonalAccessToken() {
+			response.Error(c, 403, "This endpoint cannot be used with a personal access token")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireRole allows the request only when the authenticated user has the
+// given role. It must run after Auth.
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, _ := c.Get("user")
+		if u, ok := user.(*models.User); !ok || u.Role != role {
+			response.Error(c, 403, "Insufficient permissions")
+			c.Abort()
+			return
+		}
+
 		c.Next()
 	}
 }
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"mamonedz/pkg/money"
+
+	"github.com/google/uuid"
+)
+
+const (
+	AccountTypeCash       = "cash"
+	AccountTypeBank       = "bank"
+	AccountTypeEWallet    = "ewallet"
+	AccountTypeCreditCard = "credit_card"
+)
+
+// Account is where money is kept: a wallet, bank account, e-wallet or credit
+// card. Its balance is never stored; it is the opening balance plus the
+// account's income minus its expenses. Transactions on an account are always
+// in the account's currency.
+type Account struct {
+	ID             uuid.UUID    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID         uuid.UUID    `gorm:"type:uuid;not null;index" json:"user_id"`
+	Name           string       `gorm:"type:varchar(100);not null" json:"name"`
+	Type           string       `gorm:"type:varchar(20);not null" json:"type"`
+	OpeningBalance money.Amount `gorm:"type:decimal(15,2);not null;default:0" json:"opening_balance"`
+	Currency       string       `gorm:"type:varchar(3);not null;default:'IDR'" json:"currency"`
+	CreatedAt      time.Time    `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt      time.Time    `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+type AccountWithBalance struct {
+	Account
+	Balance money.Amount `json:"balance"`
+}
+
+type CreateAccountRequest struct {
+	Name           string       `json:"name" validate:"required,min=1,max=100"`
+	Type           string       `json:"type" validate:"required,oneof=cash bank ewallet credit_card"`
+	OpeningBalance money.Amount `json:"opening_balance"`
+	Currency       string       `json:"currency" validate:"omitempty,iso4217"`
+}
+
+type UpdateAccountRequest struct {
+	Name           *string       `json:"name" validate:"omitempty,min=1,max=100"`
+	Type           *string       `json:"type" validate:"omitempty,oneof=cash bank ewallet credit_card"`
+	OpeningBalance *money.Amount `json:"opening_balance"`
+	Currency       *string       `json:"currency" validate:"omitempty,iso4217"`
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"time"
+
+	"mamonedz/pkg/money"
+
+	"github.com/google/uuid"
+)
+
+type UserFilter struct {
+	Search   string
+	Role     string
+	Disabled *bool
+	Limit    int
+	Offset   int
+}
+
+// AdminUserResponse is the view of a user given to administrators. It never
+// includes anything the user recorded, such as expenses or notes.
+type AdminUserResponse struct {
+	ID            uuid.UUID  `json:"id"`
+	Name          string     `json:"name"`
+	Email         string     `json:"email"`
+	Role          string     `json:"role"`
+	EmailVerified bool       `json:"email_verified"`
+	MFAEnabled    bool       `json:"mfa_enabled"`
+	DisabledAt    *time.Time `json:"disabled_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+func (u *User) ToAdminResponse() *AdminUserResponse {
+	return &AdminUserResponse{
+		ID:            u.ID,
+		Name:          u.Name,
+		Email:         u.Email,
+		Role:          u.Role,
+		EmailVerified: u.EmailVerifiedAt != nil,
+		MFAEnabled:    u.TOTPEnabledAt != nil,
+		DisabledAt:    u.DisabledAt,
+		CreatedAt:     u.CreatedAt,
+	}
+}
+
+type UpdateRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=user admin"`
+}
+
+type PlatformStats struct {
+	TotalUsers    int64                   `json:"total_users"`
+	VerifiedUsers int64                   `json:"verified_users"`
+	DisabledUsers int64                   `json:"disabled_users"`
+	Admins        int64                   `json:"admins"`
+	NewUsers      int64                   `json:"new_users"`
+	ActiveUsers   int64                   `json:"active_users"`
+	TotalExpenses int64                   `json:"total_expenses"`
+	ByCurrency    []PlatformCurrencyStats `json:"by_currency"`
+	ByCategory    []PlatformCategoryStats `json:"by_category"`
+}
+
+// PlatformCurrencyStats totals expenses of all users recorded in Currency.
+// Amounts in different currencies are never added up.
+type PlatformCurrencyStats struct {
+	Currency string       `json:"currency"`
+	Total    money.Amount `json:"total"`
+	Count    int          `json:"count"`
+}
+
+// PlatformCustomCategory is the category reported for expenses filed under
+// categories users created or renamed themselves, whose names are private.
+const PlatformCustomCategory = "custom"
+
+// PlatformCategoryStats groups expenses of all users by default category
+// name and currency.
+type PlatformCategoryStats struct {
+	Category string       `json:"category"`
+	Currency string       `json:"currency"`
+	Total    money.Amount `json:"total"`
+	Count    int          `json:"count"`
+}
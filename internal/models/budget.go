@@ -0,0 +1,74 @@
+package models
+
+import (
+	"time"
+
+	"mamonedz/pkg/money"
+
+	"github.com/google/uuid"
+)
+
+// BudgetAlertThresholds are the percentages of a budget at which an alert is
+// raised, once per budget and month.
+var BudgetAlertThresholds = []int{80, 100}
+
+// Budget limits monthly spending in a category and its subcategories, or in
+// total when CategoryID is nil, from StartMonth on. Amounts are in the
+// user's base currency. With Rollover, whatever was left unspent in a month
+// is added to the next month's budget.
+//
+// A user has at most one budget per category and one overall budget. Postgres
+// treats NULLs as distinct in unique indexes, so the overall budget needs its
+// own partial index.
+type Budget struct {
+	ID         uuid.UUID    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID     uuid.UUID    `gorm:"type:uuid;not null;index;uniqueIndex:idx_budget_user_category;uniqueIndex:idx_budget_user_overall,where:category_id IS NULL" json:"user_id"`
+	CategoryID *uuid.UUID   `gorm:"type:uuid;index;uniqueIndex:idx_budget_user_category" json:"category_id"`
+	Amount     money.Amount `gorm:"type:decimal(15,2);not null" json:"amount"`
+	Rollover   bool         `gorm:"not null;default:false" json:"rollover"`
+	StartMonth time.Time    `gorm:"type:date;not null" json:"start_month"`
+	CreatedAt  time.Time    `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt  time.Time    `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+// CreateBudgetRequest creates an overall budget when CategoryID is nil.
+// StartMonth is YYYY-MM and defaults to the current month.
+type CreateBudgetRequest struct {
+	CategoryID *uuid.UUID   `json:"category_id"`
+	Amount     money.Amount `json:"amount" validate:"required,gt=0"`
+	Rollover   bool         `json:"rollover"`
+	StartMonth string       `json:"start_month"`
+}
+
+type UpdateBudgetRequest struct {
+	Amount   *money.Amount `json:"amount" validate:"omitempty,gt=0"`
+	Rollover *bool         `json:"rollover"`
+}
+
+// BudgetStatus is a budget's state for one month. Budgeted is the monthly
+// amount plus any rollover; Remaining is negative once it is overspent.
+type BudgetStatus struct {
+	BudgetID    uuid.UUID    `json:"budget_id"`
+	CategoryID  *uuid.UUID   `json:"category_id"`
+	Category    string       `json:"category,omitempty"`
+	Month       string       `json:"month"`
+	Amount      money.Amount `json:"amount"`
+	Rollover    money.Amount `json:"rollover"`
+	Budgeted    money.Amount `json:"budgeted"`
+	Spent       money.Amount `json:"spent"`
+	Remaining   money.Amount `json:"remaining"`
+	PercentUsed float64      `json:"percent_used"`
+}
+
+// BudgetAlert records that spending crossed Threshold percent of a budget in
+// Month.
+type BudgetAlert struct {
+	ID        uuid.UUID    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID    uuid.UUID    `gorm:"type:uuid;not null;index" json:"user_id"`
+	BudgetID  uuid.UUID    `gorm:"type:uuid;not null;uniqueIndex:idx_budget_alert_month_threshold" json:"budget_id"`
+	Month     time.Time    `gorm:"type:date;not null;uniqueIndex:idx_budget_alert_month_threshold" json:"month"`
+	Threshold int          `gorm:"not null;uniqueIndex:idx_budget_alert_month_threshold" json:"threshold"`
+	Budgeted  money.Amount `gorm:"type:decimal(15,2);not null" json:"budgeted"`
+	Spent     money.Amount `gorm:"type:decimal(15,2);not null" json:"spent"`
+	CreatedAt time.Time    `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+}
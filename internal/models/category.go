@@ -0,0 +1,87 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultCategories are created for every new user, in this order.
+var DefaultCategories = []Category{
+	{Type: TransactionTypeExpense, Name: "makanan", Color: "#F97316", Icon: "utensils"},
+	{Type: TransactionTypeExpense, Name: "transportasi", Color: "#3B82F6", Icon: "car"},
+	{Type: TransactionTypeExpense, Name: "hiburan", Color: "#A855F7", Icon: "film"},
+	{Type: TransactionTypeExpense, Name: "belanja", Color: "#EC4899", Icon: "shopping-bag"},
+	{Type: TransactionTypeExpense, Name: "kesehatan", Color: "#EF4444", Icon: "heart-pulse"},
+	{Type: TransactionTypeExpense, Name: "pendidikan", Color: "#22C55E", Icon: "book"},
+	{Type: TransactionTypeExpense, Name: "lainnya", Color: "#6B7280", Icon: "ellipsis"},
+	{Type: TransactionTypeIncome, Name: "gaji", Color: "#16A34A", Icon: "briefcase"},
+	{Type: TransactionTypeIncome, Name: "freelance", Color: "#0EA5E9", Icon: "laptop"},
+	{Type: TransactionTypeIncome, Name: "hadiah", Color: "#F59E0B", Icon: "gift"},
+	{Type: TransactionTypeIncome, Name: "lainnya", Color: "#6B7280", Icon: "ellipsis"},
+}
+
+// NewDefaultCategories returns a fresh copy of the default categories of
+// the given types owned by userID, ready to be inserted. With no types it
+// returns all of them.
+func NewDefaultCategories(userID uuid.UUID, types ...string) []Category {
+	var categories []Category
+	order := make(map[string]int)
+	for _, c := range DefaultCategories {
+		if len(types) > 0 && !containsString(types, c.Type) {
+			continue
+		}
+		categories = append(categories, Category{
+			UserID:    userID,
+			Type:      c.Type,
+			Name:      c.Name,
+			Color:     c.Color,
+			Icon:      c.Icon,
+			SortOrder: order[c.Type],
+		})
+		order[c.Type]++
+	}
+	return categories
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+type Category struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_category_user_type_name" json:"user_id"`
+	Type      string     `gorm:"type:varchar(10);not null;default:'expense';uniqueIndex:idx_category_user_type_name" json:"type"`
+	ParentID  *uuid.UUID `gorm:"type:uuid;index" json:"parent_id"`
+	Name      string     `gorm:"type:varchar(50);not null;uniqueIndex:idx_category_user_type_name" json:"name"`
+	Color     string     `gorm:"type:varchar(7)" json:"color"`
+	Icon      string     `gorm:"type:varchar(50)" json:"icon"`
+	SortOrder int        `gorm:"not null;default:0" json:"sort_order"`
+	CreatedAt time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+type CreateCategoryRequest struct {
+	Type      string     `json:"type" validate:"omitempty,oneof=expense income"`
+	Name      string     `json:"name" validate:"required,min=1,max=50"`
+	ParentID  *uuid.UUID `json:"parent_id"`
+	Color     string     `json:"color" validate:"omitempty,hexcolor"`
+	Icon      string     `json:"icon" validate:"max=50"`
+	SortOrder *int       `json:"sort_order" validate:"omitempty,min=0"`
+}
+
+// UpdateCategoryRequest moves the category under ParentID when it is set.
+// Setting MoveToRoot makes it a top-level category instead.
+type UpdateCategoryRequest struct {
+	Name       *string    `json:"name" validate:"omitempty,min=1,max=50"`
+	ParentID   *uuid.UUID `json:"parent_id" validate:"excluded_with=MoveToRoot"`
+	MoveToRoot bool       `json:"move_to_root"`
+	Color      *string    `json:"color" validate:"omitempty,hexcolor"`
+	Icon       *string    `json:"icon" validate:"omitempty,max=50"`
+	SortOrder  *int       `json:"sort_order" validate:"omitempty,min=0"`
+}
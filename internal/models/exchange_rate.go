@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultCurrency is the currency of users, accounts and expenses that do not
+// name one.
+const DefaultCurrency = "IDR"
+
+// ExchangeRate says that on Date one unit of BaseCurrency was worth Rate units
+// of QuoteCurrency. A rate also converts the other way, as its inverse.
+type ExchangeRate struct {
+	ID            uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	BaseCurrency  string    `gorm:"type:varchar(3);not null;uniqueIndex:idx_exchange_rate_pair_date" json:"base_currency"`
+	QuoteCurrency string    `gorm:"type:varchar(3);not null;uniqueIndex:idx_exchange_rate_pair_date" json:"quote_currency"`
+	Date          time.Time `gorm:"type:date;not null;uniqueIndex:idx_exchange_rate_pair_date" json:"date"`
+	Rate          float64   `gorm:"type:decimal(20,10);not null" json:"rate"`
+	CreatedAt     time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt     time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+type CreateExchangeRateRequest struct {
+	BaseCurrency  string  `json:"base_currency" validate:"required,iso4217"`
+	QuoteCurrency string  `json:"quote_currency" validate:"required,iso4217,nefield=BaseCurrency"`
+	Date          string  `json:"date" validate:"required"`
+	Rate          float64 `json:"rate" validate:"required,gt=0"`
+}
+
+type ExchangeRateFilter struct {
+	BaseCurrency  string
+	QuoteCurrency string
+	Limit         int
+	Offset        int
+}
+
+type ImportRatesResult struct {
+	Imported int `json:"imported"`
+}
+
+type UpdateBaseCurrencyRequest struct {
+	BaseCurrency string `json:"base_currency" validate:"required,iso4217"`
+}
@@ -3,67 +3,155 @@ package models
 import (
 	"time"
 
+	"mamonedz/pkg/money"
+
 	"github.com/google/uuid"
 )
 
-var ValidCategories = map[string]bool{
-	"makanan":      true,
-	"transportasi": true,
-	"hiburan":      true,
-	"belanja":      true,
-	"kesehatan":    true,
-	"pendidikan":   true,
-	"lainnya":      true,
-}
+const (
+	TransactionTypeExpense = "expense"
+	TransactionTypeIncome  = "income"
+	// TransactionTypeAll is only used as a filter value.
+	TransactionTypeAll = "all"
+)
 
+// Expense is a single transaction. Despite the name it covers both spending
+// and income, told apart by Type; existing clients that never send a type
+// only ever see expenses.
+//
+// Expense references its category by ID. Category holds a copy of the
+// category name, kept in sync on rename, so listings and filters by name do
+// not need a join. AccountID optionally records which account the money came
+// from or went to, and RecurringID the template that generated it.
+//
+// Every transaction belongs to a ledger, which its members can all see.
+// UserID is who recorded it and PaidBy the member whose money it was; tags,
+// category and account are the recorder's.
+//
+// Amount is in Currency. BaseAmount is the same amount converted into the
+// ledger's base currency with the latest rate on or before Date; it is nil
+// while no such rate is known.
 type Expense struct {
-	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	UserID    uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
-	Amount    float64   `gorm:"type:decimal(15,2);not null" json:"amount"`
-	Category  string    `gorm:"type:varchar(50);not null;index" json:"category"`
-	Date      time.Time `gorm:"type:date;not null;index" json:"date"`
-	Note      *string   `gorm:"type:text" json:"note,omitempty"`
-	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
-	UpdatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+	ID           uuid.UUID     `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID       uuid.UUID     `gorm:"type:uuid;not null;index" json:"user_id"`
+	LedgerID     uuid.UUID     `gorm:"type:uuid;index" json:"ledger_id"`
+	PaidBy       uuid.UUID     `gorm:"type:uuid;index" json:"paid_by"`
+	Type         string        `gorm:"type:varchar(10);not null;default:'expense';index" json:"type"`
+	Amount       money.Amount  `gorm:"type:decimal(15,2);not null" json:"amount"`
+	Currency     string        `gorm:"type:varchar(3);not null;default:'IDR'" json:"currency"`
+	BaseAmount   *money.Amount `gorm:"type:decimal(15,2)" json:"base_amount"`
+	BaseCurrency string        `gorm:"type:varchar(3)" json:"base_currency"`
+	ExchangeRate *float64      `gorm:"type:decimal(20,10)" json:"exchange_rate"`
+	CategoryID   uuid.UUID     `gorm:"type:uuid;index" json:"category_id"`
+	Category     string        `gorm:"type:varchar(50);not null;index" json:"category"`
+	AccountID    *uuid.UUID    `gorm:"type:uuid;index" json:"account_id,omitempty"`
+	RecurringID  *uuid.UUID    `gorm:"type:uuid;uniqueIndex:idx_expense_recurring_date" json:"recurring_id,omitempty"`
+	Date         time.Time     `gorm:"type:date;not null;index;uniqueIndex:idx_expense_recurring_date" json:"date"`
+	Note         *string       `gorm:"type:text" json:"note,omitempty"`
+	Tags         []Tag         `gorm:"many2many:expense_tags" json:"tags"`
+	CreatedAt    time.Time     `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt    time.Time     `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
 }
 
+// CreateExpenseRequest accepts either a category ID or, for older clients, a
+// category name. Currency defaults to the account's currency, or to the
+// ledger's base currency when there is no account. The transaction goes into
+// LedgerID, by default the user's personal ledger; PaidBy defaults to the
+// user and must be a member of the ledger.
 type CreateExpenseRequest struct {
-	Amount   float64 `json:"amount" validate:"required,gt=0"`
-	Category string  `json:"category" validate:"required,validcategory"`
-	Date     string  `json:"date" validate:"required"`
-	Note     *string `json:"note"`
+	Type       string       `json:"type" validate:"omitempty,oneof=expense income"`
+	Amount     money.Amount `json:"amount" validate:"required,gt=0"`
+	Currency   string       `json:"currency" validate:"omitempty,iso4217"`
+	CategoryID *uuid.UUID   `json:"category_id" validate:"required_without=Category"`
+	Category   string       `json:"category" validate:"required_without=CategoryID,max=50"`
+	AccountID  *uuid.UUID   `json:"account_id"`
+	LedgerID   *uuid.UUID   `json:"ledger_id"`
+	PaidBy     *uuid.UUID   `json:"paid_by"`
+	Date       string       `json:"date" validate:"required"`
+	Note       *string      `json:"note"`
+	Tags       []string     `json:"tags" validate:"omitempty,dive,min=1,max=50"`
 }
 
+// UpdateExpenseRequest moves the transaction to AccountID when set; set
+// RemoveAccount to detach it from its account instead.
 type UpdateExpenseRequest struct {
-	Amount   *float64 `json:"amount" validate:"omitempty,gt=0"`
-	Category *string  `json:"category" validate:"omitempty,validcategory"`
-	Date     *string  `json:"date"`
-	Note     *string  `json:"note"`
+	Amount        *money.Amount `json:"amount" validate:"omitempty,gt=0"`
+	Currency      *string       `json:"currency" validate:"omitempty,iso4217"`
+	CategoryID    *uuid.UUID    `json:"category_id"`
+	Category      *string       `json:"category" validate:"omitempty,max=50"`
+	AccountID     *uuid.UUID    `json:"account_id" validate:"excluded_with=RemoveAccount"`
+	RemoveAccount bool          `json:"remove_account"`
+	PaidBy        *uuid.UUID    `json:"paid_by"`
+	Date          *string       `json:"date"`
+	Note          *string       `json:"note"`
+	Tags          *[]string     `json:"tags" validate:"omitempty,dive,min=1,max=50"`
 }
 
+// ExpenseFilter lists the transactions of LedgerID, which must be one of
+// UserID's ledgers. The service fills in their personal ledger when the
+// request names none.
 type ExpenseFilter struct {
-	UserID    uuid.UUID
-	StartDate *time.Time
-	EndDate   *time.Time
-	Category  *string
-	Limit     int
-	Offset    int
+	UserID      uuid.UUID
+	LedgerID    *uuid.UUID
+	Type        string
+	StartDate   *time.Time
+	EndDate     *time.Time
+	Category    *string
+	CategoryID  *uuid.UUID
+	AccountID   *uuid.UUID
+	RecurringID *uuid.UUID
+	Tags        []string
+	TagMatch    string
+	Limit       int
+	Offset      int
+}
+
+// StatsQuery selects the period of GET /expenses/stats, whether the
+// breakdowns cover expenses or income, and, with GroupBy "tag", adds totals
+// per tag. LedgerID defaults to the user's personal ledger.
+type StatsQuery struct {
+	LedgerID *uuid.UUID
+	Period   string
+	Type     string
+	GroupBy  string
 }
 
+// CategoryStats is a node of the category tree in ExpenseStats. Total and
+// Count cover expenses filed directly under the category; the rolled-up
+// values include all of its descendants.
 type CategoryStats struct {
-	Category string  `json:"category"`
-	Total    float64 `json:"total"`
-	Count    int     `json:"count"`
+	CategoryID    uuid.UUID       `json:"category_id"`
+	Category      string          `json:"category"`
+	Total         money.Amount    `json:"total"`
+	Count         int             `json:"count"`
+	RolledUpTotal money.Amount    `json:"rolled_up_total"`
+	RolledUpCount int             `json:"rolled_up_count"`
+	Children      []CategoryStats `json:"children,omitempty"`
 }
 
 type DailyTrend struct {
-	Date  string  `json:"date"`
-	Total float64 `json:"total"`
+	Date  string       `json:"date"`
+	Total money.Amount `json:"total"`
 }
 
+// ExpenseStats summarizes a period. Total, Count and the breakdowns cover the
+// requested transaction type; the income and expense totals, net and savings
+// rate always cover both. All amounts are in Currency, the ledger's base
+// currency; Unconverted counts transactions left out of the totals for lack
+// of an exchange rate.
 type ExpenseStats struct {
-	Total      float64         `json:"total"`
-	Count      int             `json:"count"`
-	ByCategory []CategoryStats `json:"by_category"`
-	DailyTrend []DailyTrend    `json:"daily_trend"`
+	Type         string       `json:"type"`
+	Currency     string       `json:"currency"`
+	Total        money.Amount `json:"total"`
+	Count        int          `json:"count"`
+	Unconverted  int          `json:"unconverted"`
+	IncomeTotal  money.Amount `json:"income_total"`
+	ExpenseTotal money.Amount `json:"expense_total"`
+	Net          money.Amount `json:"net"`
+	// SavingsRate is the share of income not spent, in percent. It is zero
+	// when there was no income.
+	SavingsRate float64         `json:"savings_rate"`
+	ByCategory  []CategoryStats `json:"by_category"`
+	ByTag       []TagStats      `json:"by_tag,omitempty"`
+	DailyTrend  []DailyTrend    `json:"daily_trend"`
 }
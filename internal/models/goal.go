@@ -0,0 +1,114 @@
+package models
+
+import (
+	"time"
+
+	"mamonedz/pkg/money"
+
+	"github.com/google/uuid"
+)
+
+// A projection is based on the goal's average monthly contribution, or on an
+// amount the user asked about.
+const (
+	ProjectionBasisAverage   = "average"
+	ProjectionBasisRequested = "requested"
+)
+
+// SavingsGoal is an amount the user is saving towards, optionally by
+// TargetDate and in a dedicated account. Progress is the sum of its
+// contributions, in the goal's currency.
+type SavingsGoal struct {
+	ID           uuid.UUID    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID       uuid.UUID    `gorm:"type:uuid;not null;index" json:"user_id"`
+	Name         string       `gorm:"type:varchar(100);not null" json:"name"`
+	TargetAmount money.Amount `gorm:"type:decimal(15,2);not null" json:"target_amount"`
+	Currency     string       `gorm:"type:varchar(3);not null;default:'IDR'" json:"currency"`
+	TargetDate   *time.Time   `gorm:"type:date" json:"target_date,omitempty"`
+	AccountID    *uuid.UUID   `gorm:"type:uuid;index" json:"account_id,omitempty"`
+	Note         *string      `gorm:"type:text" json:"note,omitempty"`
+	CreatedAt    time.Time    `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt    time.Time    `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+// GoalContribution adds a positive Amount to a goal. A
+// contribution can record a transfer into the goal's account, which then
+// cannot back another contribution.
+type GoalContribution struct {
+	ID         uuid.UUID    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID     uuid.UUID    `gorm:"type:uuid;not null;index" json:"user_id"`
+	GoalID     uuid.UUID    `gorm:"type:uuid;not null;index" json:"goal_id"`
+	Amount     money.Amount `gorm:"type:decimal(15,2);not null" json:"amount"`
+	Date       time.Time    `gorm:"type:date;not null;index" json:"date"`
+	TransferID *uuid.UUID   `gorm:"type:uuid;uniqueIndex" json:"transfer_id,omitempty"`
+	Note       *string      `gorm:"type:text" json:"note,omitempty"`
+	CreatedAt  time.Time    `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// CreateGoalRequest takes its currency from AccountID when one is given,
+// otherwise from Currency or the user's base currency.
+type CreateGoalRequest struct {
+	Name         string       `json:"name" validate:"required,min=1,max=100"`
+	TargetAmount money.Amount `json:"target_amount" validate:"required,gt=0"`
+	Currency     string       `json:"currency" validate:"omitempty,iso4217"`
+	TargetDate   *string      `json:"target_date"`
+	AccountID    *uuid.UUID   `json:"account_id"`
+	Note         *string      `json:"note"`
+}
+
+// UpdateGoalRequest cannot change the currency; a new account has to use the
+// goal's currency.
+type UpdateGoalRequest struct {
+	Name            *string       `json:"name" validate:"omitempty,min=1,max=100"`
+	TargetAmount    *money.Amount `json:"target_amount" validate:"omitempty,gt=0"`
+	TargetDate      *string       `json:"target_date" validate:"excluded_with=ClearTargetDate"`
+	ClearTargetDate bool          `json:"clear_target_date"`
+	AccountID       *uuid.UUID    `json:"account_id" validate:"excluded_with=RemoveAccount"`
+	RemoveAccount   bool          `json:"remove_account"`
+	Note            *string       `json:"note"`
+}
+
+// CreateContributionRequest links TransferID when given, in which case
+// Amount and Date default to the transfer's.
+type CreateContributionRequest struct {
+	Amount     money.Amount `json:"amount" validate:"required_without=TransferID,omitempty,gt=0"`
+	Date       string       `json:"date" validate:"required_without=TransferID"`
+	TransferID *uuid.UUID   `json:"transfer_id"`
+	Note       *string      `json:"note"`
+}
+
+// GoalWithProgress is a goal with its progress as of today. MonthsLeft
+// counts the months after the current one up to the target date, and
+// MonthlyNeeded is what has to be saved in each of them to reach the target;
+// when the target month has come, all of Remaining is needed now. Both are
+// omitted without a target date.
+type GoalWithProgress struct {
+	SavingsGoal
+	Saved           money.Amount  `json:"saved"`
+	Remaining       money.Amount  `json:"remaining"`
+	ProgressPercent float64       `json:"progress_percent"`
+	MonthsLeft      *int          `json:"months_left,omitempty"`
+	MonthlyNeeded   *money.Amount `json:"monthly_needed,omitempty"`
+}
+
+// GoalProjection extrapolates a goal from MonthlyContribution, saved every
+// month from next month on. ProjectedMonth is when the target is reached, or
+// nil if it never is; OnTrack compares it with the target date.
+type GoalProjection struct {
+	GoalID              uuid.UUID             `json:"goal_id"`
+	Saved               money.Amount          `json:"saved"`
+	Remaining           money.Amount          `json:"remaining"`
+	MonthlyContribution money.Amount          `json:"monthly_contribution"`
+	Basis               string                `json:"basis"`
+	ProjectedMonth      *string               `json:"projected_month"`
+	TargetDate          *time.Time            `json:"target_date,omitempty"`
+	OnTrack             *bool                 `json:"on_track,omitempty"`
+	MonthlyNeeded       *money.Amount         `json:"monthly_needed,omitempty"`
+	Schedule            []GoalProjectionMonth `json:"schedule"`
+}
+
+type GoalProjectionMonth struct {
+	Month        string       `json:"month"`
+	Contribution money.Amount `json:"contribution"`
+	Saved        money.Amount `json:"saved"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ExternalIdentity links a user to an account at an OpenID Connect provider.
+type ExternalIdentity struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	Provider  string    `gorm:"type:varchar(50);not null;uniqueIndex:idx_identity_provider_subject" json:"provider"`
+	Subject   string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_identity_provider_subject" json:"subject"`
+	Email     string    `gorm:"type:varchar(255)" json:"email"`
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// OIDCState remembers an authorization request between the redirect to the
+// provider and the callback. It is deleted when consumed.
+type OIDCState struct {
+	State        string    `gorm:"type:varchar(64);primary_key" json:"-"`
+	Provider     string    `gorm:"type:varchar(50);not null" json:"-"`
+	Nonce        string    `gorm:"type:varchar(64);not null" json:"-"`
+	CodeVerifier string    `gorm:"type:varchar(128);not null" json:"-"`
+	ExpiresAt    time.Time `gorm:"not null;index" json:"-"`
+}
+
+type OIDCAuthorizationResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+}
+
+type OIDCCallbackRequest struct {
+	Code  string `json:"code" validate:"required"`
+	State string `json:"state" validate:"required"`
+}
@@ -0,0 +1,97 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	LedgerRoleOwner  = "owner"
+	LedgerRoleEditor = "editor"
+	LedgerRoleViewer = "viewer"
+
+	// PersonalLedgerName names the ledger every user gets on registration.
+	PersonalLedgerName = "Personal"
+)
+
+// LedgerWriteRoles may add, change and delete a ledger's transactions.
+var LedgerWriteRoles = []string{LedgerRoleOwner, LedgerRoleEditor}
+
+// Ledger groups transactions that its members share, such as a household's.
+// UserID is the owner. Every user has exactly one personal ledger, which
+// cannot be shared or deleted and always uses their base currency; budgets
+// cover it alone.
+type Ledger struct {
+	ID           uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID       uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	Name         string    `gorm:"type:varchar(100);not null" json:"name"`
+	BaseCurrency string    `gorm:"type:varchar(3);not null;default:'IDR'" json:"base_currency"`
+	Personal     bool      `gorm:"not null;default:false" json:"personal"`
+	CreatedAt    time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt    time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+// LedgerWithRole is a ledger together with the requesting user's role in it.
+type LedgerWithRole struct {
+	Ledger
+	Role string `json:"role"`
+}
+
+type LedgerMember struct {
+	LedgerID  uuid.UUID `gorm:"type:uuid;primary_key" json:"ledger_id"`
+	UserID    uuid.UUID `gorm:"type:uuid;primary_key;index" json:"user_id"`
+	Role      string    `gorm:"type:varchar(10);not null" json:"role"`
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// LedgerMemberInfo is a member as listed to the other members.
+type LedgerMemberInfo struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"joined_at"`
+}
+
+// LedgerInvitation invites whoever owns Email to join a ledger with Role.
+// Inviting the same address again replaces the pending invitation.
+type LedgerInvitation struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	LedgerID  uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_ledger_invitation_email" json:"ledger_id"`
+	Email     string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_ledger_invitation_email;index" json:"email"`
+	Role      string    `gorm:"type:varchar(10);not null" json:"role"`
+	InvitedBy uuid.UUID `gorm:"type:uuid;not null" json:"invited_by"`
+	ExpiresAt time.Time `gorm:"not null" json:"expires_at"`
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// PendingInvitation is an invitation as shown to the invited user.
+type PendingInvitation struct {
+	LedgerInvitation
+	LedgerName string `json:"ledger_name"`
+}
+
+// CreateLedgerRequest creates a shared ledger; BaseCurrency defaults to the
+// user's base currency.
+type CreateLedgerRequest struct {
+	Name         string `json:"name" validate:"required,min=1,max=100"`
+	BaseCurrency string `json:"base_currency" validate:"omitempty,iso4217"`
+}
+
+// UpdateLedgerRequest converts all of the ledger's transactions again when
+// BaseCurrency changes. The currency of a personal ledger follows the
+// user's base currency instead.
+type UpdateLedgerRequest struct {
+	Name         *string `json:"name" validate:"omitempty,min=1,max=100"`
+	BaseCurrency *string `json:"base_currency" validate:"omitempty,iso4217"`
+}
+
+type InviteMemberRequest struct {
+	Email string `json:"email" validate:"required,email"`
+	Role  string `json:"role" validate:"required,oneof=editor viewer"`
+}
+
+type UpdateMemberRequest struct {
+	Role string `json:"role" validate:"required,oneof=editor viewer"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// LoginAttempt tracks recent failed logins for a throttling key such as an
+// email address or a client IP.
+type LoginAttempt struct {
+	Key            string     `gorm:"type:varchar(320);primary_key" json:"key"`
+	Failures       int        `gorm:"not null;default:0" json:"failures"`
+	FirstFailureAt time.Time  `gorm:"not null" json:"first_failure_at"`
+	LastFailureAt  time.Time  `gorm:"not null" json:"last_failure_at"`
+	LockedUntil    *time.Time `json:"locked_until,omitempty"`
+}
+
+// ClientInfo describes where a request came from.
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
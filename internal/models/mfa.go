@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RecoveryCode is a single-use backup code for two-factor authentication.
+// Only the hash of the code is stored.
+type RecoveryCode struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	CodeHash  string     `gorm:"type:varchar(64);not null" json:"-"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+type MFASetupResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+type MFAEnableRequest struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
+type MFAEnableResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type MFADisableRequest struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
+
+// MFAVerifyRequest exchanges an MFA challenge token for a token pair. Code is
+// either a TOTP code or one of the recovery codes.
+type MFAVerifyRequest struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PersonalAccessTokenPrefix marks bearer tokens that are personal access
+// tokens rather than JWTs.
+const PersonalAccessTokenPrefix = "mmz_pat_"
+
+const (
+	ScopeExpensesRead  = "expenses:read"
+	ScopeExpensesWrite = "expenses:write"
+	ScopeStatsRead     = "stats:read"
+)
+
+var ValidScopes = map[string]bool{
+	ScopeExpensesRead:  true,
+	ScopeExpensesWrite: true,
+	ScopeStatsRead:     true,
+}
+
+// PersonalAccessToken is a named, scoped, long-lived token for scripts and
+// integrations. Only the hash of the token is stored.
+type PersonalAccessToken struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID      uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	Name        string     `gorm:"type:varchar(100);not null" json:"name"`
+	TokenHash   string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"`
+	TokenPrefix string     `gorm:"type:varchar(20);not null" json:"token_prefix"`
+	Scopes      []string   `gorm:"type:text;serializer:json;not null" json:"scopes"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	RevokedAt   *time.Time `json:"-"`
+	CreatedAt   time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+type CreatePersonalAccessTokenRequest struct {
+	Name          string   `json:"name" validate:"required,min=1,max=100"`
+	Scopes        []string `json:"scopes" validate:"required,min=1,dive,validscope"`
+	ExpiresInDays *int     `json:"expires_in_days" validate:"omitempty,min=1,max=365"`
+}
+
+// CreatePersonalAccessTokenResponse is the only time the plain token is
+// returned to the user.
+type CreatePersonalAccessTokenResponse struct {
+	*PersonalAccessToken
+	Token string `json:"token"`
+}
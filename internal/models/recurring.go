@@ -0,0 +1,75 @@
+package models
+
+import (
+	"time"
+
+	"mamonedz/pkg/money"
+
+	"github.com/google/uuid"
+)
+
+const (
+	FrequencyDaily   = "daily"
+	FrequencyWeekly  = "weekly"
+	FrequencyMonthly = "monthly"
+	FrequencyYearly  = "yearly"
+)
+
+// RecurringExpense is a template the scheduler turns into an expense (or
+// income) every Interval days, weeks, months or years from StartDate. Monthly
+// and yearly occurrences keep StartDate's day, moved to the last day of
+// shorter months.
+//
+// Occurrences counts occurrences that are done, whether generated or
+// skipped, and NextDate is the next one due. NextDate is nil once the
+// template has passed EndDate or reached MaxOccurrences.
+type RecurringExpense struct {
+	ID             uuid.UUID    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID         uuid.UUID    `gorm:"type:uuid;not null;index" json:"user_id"`
+	Type           string       `gorm:"type:varchar(10);not null;default:'expense'" json:"type"`
+	Amount         money.Amount `gorm:"type:decimal(15,2);not null" json:"amount"`
+	Currency       string       `gorm:"type:varchar(3);not null;default:'IDR'" json:"currency"`
+	CategoryID     uuid.UUID    `gorm:"type:uuid;not null;index" json:"category_id"`
+	AccountID      *uuid.UUID   `gorm:"type:uuid;index" json:"account_id,omitempty"`
+	Note           *string      `gorm:"type:text" json:"note,omitempty"`
+	Frequency      string       `gorm:"type:varchar(10);not null" json:"frequency"`
+	Interval       int          `gorm:"not null;default:1" json:"interval"`
+	StartDate      time.Time    `gorm:"type:date;not null" json:"start_date"`
+	EndDate        *time.Time   `gorm:"type:date" json:"end_date,omitempty"`
+	MaxOccurrences *int         `json:"max_occurrences,omitempty"`
+	Occurrences    int          `gorm:"not null;default:0" json:"occurrences"`
+	NextDate       *time.Time   `gorm:"type:date;index" json:"next_date"`
+	PausedAt       *time.Time   `json:"paused_at,omitempty"`
+	CreatedAt      time.Time    `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt      time.Time    `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+// CreateRecurringExpenseRequest accepts at most one of EndDate and
+// MaxOccurrences; without either the template repeats forever.
+type CreateRecurringExpenseRequest struct {
+	Type           string       `json:"type" validate:"omitempty,oneof=expense income"`
+	Amount         money.Amount `json:"amount" validate:"required,gt=0"`
+	Currency       string       `json:"currency" validate:"omitempty,iso4217"`
+	CategoryID     uuid.UUID    `json:"category_id" validate:"required"`
+	AccountID      *uuid.UUID   `json:"account_id"`
+	Note           *string      `json:"note"`
+	Frequency      string       `json:"frequency" validate:"required,oneof=daily weekly monthly yearly"`
+	Interval       int          `json:"interval" validate:"omitempty,min=1,max=365"`
+	StartDate      string       `json:"start_date" validate:"required"`
+	EndDate        *string      `json:"end_date" validate:"excluded_with=MaxOccurrences"`
+	MaxOccurrences *int         `json:"max_occurrences" validate:"omitempty,min=1"`
+}
+
+// UpdateRecurringExpenseRequest changes what future occurrences look like.
+// The schedule itself cannot be changed; send ClearEnd to make the template
+// repeat forever.
+type UpdateRecurringExpenseRequest struct {
+	Amount         *money.Amount `json:"amount" validate:"omitempty,gt=0"`
+	CategoryID     *uuid.UUID    `json:"category_id"`
+	AccountID      *uuid.UUID    `json:"account_id" validate:"excluded_with=RemoveAccount"`
+	RemoveAccount  bool          `json:"remove_account"`
+	Note           *string       `json:"note"`
+	EndDate        *string       `json:"end_date" validate:"excluded_with=MaxOccurrences ClearEnd"`
+	MaxOccurrences *int          `json:"max_occurrences" validate:"omitempty,min=1,excluded_with=ClearEnd"`
+	ClearEnd       bool          `json:"clear_end"`
+}
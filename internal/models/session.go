@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session is created on every login and lives as long as its refresh token
+// family; the refresh tokens of a session use the session ID as family ID.
+type Session struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID     uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	UserAgent  string     `gorm:"type:varchar(512)" json:"user_agent"`
+	IP         string     `gorm:"type:varchar(64)" json:"ip"`
+	CreatedAt  time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	LastSeenAt time.Time  `gorm:"not null" json:"last_seen_at"`
+	RevokedAt  *time.Time `json:"-"`
+}
+
+type SessionResponse struct {
+	Session
+	Current bool `json:"current"`
+}
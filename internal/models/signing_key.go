@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// SigningKey is a key pair used to sign access tokens. A new key is created
+// every rotation interval; older keys are kept only for verification until
+// every token they signed has expired.
+type SigningKey struct {
+	KID        string    `gorm:"type:varchar(64);primary_key" json:"kid"`
+	Algorithm  string    `gorm:"type:varchar(16);not null" json:"alg"`
+	PrivateKey string    `gorm:"type:text;not null" json:"-"`
+	PublicKey  string    `gorm:"type:text;not null" json:"-"`
+	CreatedAt  time.Time `gorm:"not null;index" json:"created_at"`
+}
+
+// JWK is a public key in JSON Web Key format (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"mamonedz/pkg/money"
+
+	"github.com/google/uuid"
+)
+
+const (
+	TagMatchAny = "any"
+	TagMatchAll = "all"
+)
+
+// Tag is a free-form label. Expenses and tags are linked through the
+// expense_tags join table.
+type Tag struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_tag_user_name" json:"user_id"`
+	Name      string    `gorm:"type:varchar(50);not null;uniqueIndex:idx_tag_user_name" json:"name"`
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// TagWithUsage is a tag together with the number of expenses carrying it.
+type TagWithUsage struct {
+	Tag
+	ExpenseCount int `json:"expense_count"`
+}
+
+type CreateTagRequest struct {
+	Name string `json:"name" validate:"required,min=1,max=50"`
+}
+
+type RenameTagRequest struct {
+	Name string `json:"name" validate:"required,min=1,max=50"`
+}
+
+// MergeTagRequest moves every expense of the source tag to IntoID and
+// deletes the source tag.
+type MergeTagRequest struct {
+	IntoID uuid.UUID `json:"into_id" validate:"required"`
+}
+
+type TagStats struct {
+	TagID uuid.UUID    `json:"tag_id"`
+	Tag   string       `json:"tag"`
+	Total money.Amount `json:"total"`
+	Count int          `json:"count"`
+}
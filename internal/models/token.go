@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RefreshToken is a long-lived opaque token used to obtain new access tokens.
+// Only the SHA-256 hash of the token is stored. Every rotation creates a new
+// row in the same family so that reuse of an old token can revoke them all.
+type RefreshToken struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	FamilyID  uuid.UUID  `gorm:"type:uuid;not null;index" json:"family_id"`
+	TokenHash string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	RotatedAt *time.Time `json:"rotated_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// RevokedToken records an access token ID (jti) that was logged out before
+// it expired. Rows are purged once ExpiresAt has passed.
+type RevokedToken struct {
+	JTI       string    `gorm:"type:varchar(64);primary_key" json:"jti"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP;index" json:"created_at"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+
+	"mamonedz/pkg/money"
+
+	"github.com/google/uuid"
+)
+
+// Transfer moves money from one of the user's accounts to another. It is not
+// spending, so it never shows up in stats; a fee, if any, is recorded as a
+// separate expense on the source account and linked through FeeExpenseID.
+type Transfer struct {
+	ID            uuid.UUID    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID        uuid.UUID    `gorm:"type:uuid;not null;index" json:"user_id"`
+	FromAccountID uuid.UUID    `gorm:"type:uuid;not null;index" json:"from_account_id"`
+	ToAccountID   uuid.UUID    `gorm:"type:uuid;not null;index" json:"to_account_id"`
+	Amount        money.Amount `gorm:"type:decimal(15,2);not null" json:"amount"`
+	Fee           money.Amount `gorm:"type:decimal(15,2);not null;default:0" json:"fee"`
+	FeeExpenseID  *uuid.UUID   `gorm:"type:uuid;index" json:"fee_expense_id,omitempty"`
+	Date          time.Time    `gorm:"type:date;not null;index" json:"date"`
+	Note          *string      `gorm:"type:text" json:"note,omitempty"`
+	CreatedAt     time.Time    `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt     time.Time    `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+// CreateTransferRequest records a transfer. The fee is filed under
+// FeeCategoryID, or the expense category "lainnya" when it is not given.
+type CreateTransferRequest struct {
+	FromAccountID uuid.UUID    `json:"from_account_id" validate:"required,nefield=ToAccountID"`
+	ToAccountID   uuid.UUID    `json:"to_account_id" validate:"required"`
+	Amount        money.Amount `json:"amount" validate:"required,gt=0"`
+	Fee           money.Amount `json:"fee" validate:"gte=0"`
+	FeeCategoryID *uuid.UUID   `json:"fee_category_id"`
+	Date          string       `json:"date" validate:"required"`
+	Note          *string      `json:"note"`
+}
+
+type TransferFilter struct {
+	UserID    uuid.UUID
+	AccountID *uuid.UUID
+	Limit     int
+	Offset    int
+}
+
+const (
+	HistoryKindTransferIn  = "transfer_in"
+	HistoryKindTransferOut = "transfer_out"
+)
+
+// AccountHistoryEntry is one line of an account's history: an expense, an
+// income or a transfer in or out. Amount is signed, negative when money left
+// the account.
+type AccountHistoryEntry struct {
+	ID                    uuid.UUID    `json:"id"`
+	Kind                  string       `json:"kind"`
+	Date                  time.Time    `json:"date"`
+	Amount                money.Amount `json:"amount"`
+	Category              *string      `json:"category,omitempty"`
+	CounterpartyAccountID *uuid.UUID   `json:"counterparty_account_id,omitempty"`
+	Note                  *string      `json:"note,omitempty"`
+	CreatedAt             time.Time    `json:"created_at"`
+}
@@ -6,13 +6,31 @@ import (
 	"github.com/google/uuid"
 )
 
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+var ValidRoles = map[string]bool{
+	RoleUser:  true,
+	RoleAdmin: true,
+}
+
 type User struct {
-	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	Name      string    `gorm:"type:varchar(100);not null" json:"name"`
-	Email     string    `gorm:"type:varchar(255);uniqueIndex;not null" json:"email"`
-	Password  string    `gorm:"type:varchar(255);not null" json:"-"`
-	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
-	UpdatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+	ID              uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Name            string     `gorm:"type:varchar(100);not null" json:"name"`
+	Email           string     `gorm:"type:varchar(255);uniqueIndex;not null" json:"email"`
+	Password        string     `gorm:"type:varchar(255);not null" json:"-"`
+	Role            string     `gorm:"type:varchar(20);not null;default:'user';index" json:"role"`
+	BaseCurrency    string     `gorm:"type:varchar(3);not null;default:'IDR'" json:"base_currency"`
+	DisabledAt      *time.Time `json:"disabled_at,omitempty"`
+	TokenVersion    int        `gorm:"not null;default:0" json:"-"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+	TOTPSecret      *string    `gorm:"type:varchar(64)" json:"-"`
+	TOTPEnabledAt   *time.Time `json:"-"`
+	TOTPLastStep    int64      `gorm:"not null;default:0" json:"-"`
+	CreatedAt       time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt       time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
 }
 
 type RegisterRequest struct {
@@ -26,21 +44,53 @@ type LoginRequest struct {
 	Password string `json:"password" validate:"required"`
 }
 
+// ChangePasswordRequest, ChangeEmailRequest and DeleteAccountRequest need the
+// current password, except for accounts without one, which confirm these
+// changes with a recent sign-in instead.
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password" validate:"required,min=6"`
+}
+
+type ChangeEmailRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password"`
+}
+
+type DeleteAccountRequest struct {
+	Password string `json:"password"`
+}
+
+// AuthResponse carries either a token pair or, when the user has two-factor
+// authentication enabled, an MFA challenge token to be exchanged at
+// /auth/mfa/verify.
 type AuthResponse struct {
-	User  *UserResponse `json:"user"`
-	Token string        `json:"token"`
+	User         *UserResponse `json:"user"`
+	Token        string        `json:"token,omitempty"`
+	RefreshToken string        `json:"refresh_token,omitempty"`
+	ExpiresIn    int64         `json:"expires_in,omitempty"`
+	MFARequired  bool          `json:"mfa_required,omitempty"`
+	MFAToken     string        `json:"mfa_token,omitempty"`
 }
 
 type UserResponse struct {
-	ID    uuid.UUID `json:"id"`
-	Name  string    `json:"name"`
-	Email string    `json:"email"`
+	ID            uuid.UUID `json:"id"`
+	Name          string    `json:"name"`
+	Email         string    `json:"email"`
+	Role          string    `json:"role"`
+	BaseCurrency  string    `json:"base_currency"`
+	EmailVerified bool      `json:"email_verified"`
+	MFAEnabled    bool      `json:"mfa_enabled"`
 }
 
 func (u *User) ToResponse() *UserResponse {
 	return &UserResponse{
-		ID:    u.ID,
-		Name:  u.Name,
-		Email: u.Email,
+		ID:            u.ID,
+		Name:          u.Name,
+		Email:         u.Email,
+		Role:          u.Role,
+		BaseCurrency:  u.BaseCurrency,
+		EmailVerified: u.EmailVerifiedAt != nil,
+		MFAEnabled:    u.TOTPEnabledAt != nil,
 	}
 }
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	TokenPurposePasswordReset     = "password_reset"
+	TokenPurposeEmailVerification = "email_verification"
+	TokenPurposeEmailChange       = "email_change"
+	TokenPurposeMagicLink         = "magic_link"
+)
+
+// UserToken is a single-use token sent to the user by email. Only the hash of
+// the token is stored.
+type UserToken struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	Purpose   string     `gorm:"type:varchar(32);not null;index" json:"purpose"`
+	TokenHash string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"`
+	Email     string     `gorm:"type:varchar(255)" json:"email,omitempty"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=6"`
+}
+
+type MagicLinkRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type MagicLinkLoginRequest struct {
+	Token string `json:"token" validate:"required"`
+}
@@ -0,0 +1,293 @@
+// Package oidc is a minimal OpenID Connect relying party: discovery,
+// authorization code flow with PKCE, and ID token validation against the
+// provider's published keys.
+package oidc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrInvalidIDToken = errors.New("invalid id token")
+	ErrExchangeFailed = errors.New("authorization code exchange failed")
+	errUnsupportedKey = errors.New("unsupported key type")
+)
+
+// discoveryTTL is how long a provider's metadata and keys are cached.
+const discoveryTTL = time.Hour
+
+type Config struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// Claims are the ID token claims used to identify the user.
+type Claims struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type Provider struct {
+	cfg    Config
+	client *http.Client
+
+	mu          sync.Mutex
+	meta        *discovery
+	keys        map[string]interface{}
+	refreshedAt time.Time
+}
+
+func NewProvider(cfg Config) *Provider {
+	return &Provider{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (p *Provider) Name() string {
+	return p.cfg.Name
+}
+
+// AuthCodeURL builds the URL the user is sent to. The code challenge is
+// derived from verifier with S256.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	meta, err := p.metadata(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	q := url.Values{}
+	q.Set("response_type", "code")
+	q.Set("client_id", p.cfg.ClientID)
+	q.Set("redirect_uri", p.cfg.RedirectURL)
+	q.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", CodeChallenge(verifier))
+	q.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return meta.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+// Exchange trades the authorization code for tokens and returns the
+// validated ID token claims.
+func (p *Provider) Exchange(ctx context.Context, code, verifier, nonce string) (*Claims, error) {
+	meta, err := p.metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.cfg.RedirectURL)
+	form.Set("code_verifier", verifier)
+	form.Set("client_id", p.cfg.ClientID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrExchangeFailed, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: token endpoint returned %d", ErrExchangeFailed, resp.StatusCode)
+	}
+
+	var tokens struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.Unmarshal(body, &tokens); err != nil || tokens.IDToken == "" {
+		return nil, fmt.Errorf("%w: no id_token in response", ErrExchangeFailed)
+	}
+
+	return p.VerifyIDToken(ctx, tokens.IDToken, nonce)
+}
+
+// VerifyIDToken checks the signature, issuer, audience, expiry and nonce of
+// an ID token.
+func (p *Provider) VerifyIDToken(ctx context.Context, raw, nonce string) (*Claims, error) {
+	meta, err := p.metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := jwt.Parse(raw, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.key(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}),
+		jwt.WithIssuer(meta.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil || !token.Valid {
+		return nil, ErrInvalidIDToken
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, ErrInvalidIDToken
+	}
+	if got, _ := claims["nonce"].(string); got != nonce {
+		return nil, ErrInvalidIDToken
+	}
+	// With several audiences the token must name us as authorized party.
+	if aud, _ := claims.GetAudience(); len(aud) > 1 {
+		if azp, _ := claims["azp"].(string); azp != p.cfg.ClientID {
+			return nil, ErrInvalidIDToken
+		}
+	}
+
+	sub, _ := claims["sub"].(string)
+	if sub == "" {
+		return nil, ErrInvalidIDToken
+	}
+	email, _ := claims["email"].(string)
+	name, _ := claims["name"].(string)
+
+	return &Claims{
+		Subject:       sub,
+		Email:         strings.ToLower(email),
+		EmailVerified: emailVerified(claims["email_verified"]),
+		Name:          name,
+	}, nil
+}
+
+// emailVerified accepts both booleans and the "true" string some providers
+// send.
+func emailVerified(v interface{}) bool {
+	switch b := v.(type) {
+	case bool:
+		return b
+	case string:
+		return b == "true"
+	default:
+		return false
+	}
+}
+
+func (p *Provider) metadata(ctx context.Context) (*discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.meta != nil && time.Since(p.refreshedAt) < discoveryTTL {
+		return p.meta, nil
+	}
+	if err := p.refresh(ctx); err != nil {
+		return nil, err
+	}
+	return p.meta, nil
+}
+
+func (p *Provider) key(ctx context.Context, kid string) (interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	// The provider may have rotated its keys; refetch once, but not more
+	// often than every minute.
+	if time.Since(p.refreshedAt) > time.Minute {
+		if err := p.refresh(ctx); err != nil {
+			return nil, err
+		}
+		if key, ok := p.keys[kid]; ok {
+			return key, nil
+		}
+	}
+	return nil, ErrInvalidIDToken
+}
+
+// refresh reloads discovery metadata and keys. Callers must hold p.mu.
+func (p *Provider) refresh(ctx context.Context) error {
+	var meta discovery
+	wellKnown := strings.TrimRight(p.cfg.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := p.getJSON(ctx, wellKnown, &meta); err != nil {
+		return fmt.Errorf("oidc discovery for %s: %w", p.cfg.Name, err)
+	}
+	if strings.TrimRight(meta.Issuer, "/") != strings.TrimRight(p.cfg.Issuer, "/") {
+		return fmt.Errorf("oidc discovery for %s: issuer mismatch %q", p.cfg.Name, meta.Issuer)
+	}
+
+	var set jwkSet
+	if err := p.getJSON(ctx, meta.JWKSURI, &set); err != nil {
+		return fmt.Errorf("oidc keys for %s: %w", p.cfg.Name, err)
+	}
+
+	p.meta = &meta
+	p.keys = set.publicKeys()
+	p.refreshedAt = time.Now()
+	return nil
+}
+
+func (p *Provider) getJSON(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
+
+// CodeChallenge derives the S256 PKCE challenge for verifier.
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"time"
+
+	"mamonedz/internal/models"
+	"mamonedz/pkg/money"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type AccountRepository interface {
+	Create(account *models.Account) error
+	GetByID(id, userID uuid.UUID) (*models.Account, error)
+	GetAllByUser(userID uuid.UUID) ([]models.Account, error)
+	Update(account *models.Account) error
+	Delete(id, userID uuid.UUID) error
+	CountTransactions(id, userID uuid.UUID) (int64, error)
+	GetMovements(userID uuid.UUID, asOf *time.Time) (map[uuid.UUID]money.Amount, error)
+	GetHistory(id, userID uuid.UUID, limit, offset int) ([]models.AccountHistoryEntry, int64, error)
+}
+
+type accountRepository struct {
+	db *gorm.DB
+}
+
+func NewAccountRepository(db *gorm.DB) AccountRepository {
+	return &accountRepository{db: db}
+}
+
+func (r *accountRepository) Create(account *models.Account) error {
+	return r.db.Create(account).Error
+}
+
+func (r *accountRepository) GetByID(id, userID uuid.UUID) (*models.Account, error) {
+	var account models.Account
+	err := r.db.First(&account, "id = ? AND user_id = ?", id, userID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+func (r *accountRepository) GetAllByUser(userID uuid.UUID) ([]models.Account, error) {
+	var accounts []models.Account
+	err := r.db.Where("user_id = ?", userID).
+		Order("created_at ASC").
+		Find(&accounts).Error
+	return accounts, err
+}
+
+func (r *accountRepository) Update(account *models.Account) error {
+	return r.db.Save(account).Error
+}
+
+// Delete removes the account. Recurring templates paying from it carry on
+// without an account, and so do savings goals kept in it.
+func (r *accountRepository) Delete(id, userID uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.RecurringExpense{}).
+			Where("account_id = ? AND user_id = ?", id, userID).
+			Update("account_id", nil).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&models.SavingsGoal{}).
+			Where("account_id = ? AND user_id = ?", id, userID).
+			Update("account_id", nil).Error
+		if err != nil {
+			return err
+		}
+		result := tx.Delete(&models.Account{}, "id = ? AND user_id = ?", id, userID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// CountTransactions counts the expenses, income and transfers that involve
+// the account.
+func (r *accountRepository) CountTransactions(id, userID uuid.UUID) (int64, error) {
+	var expenses, transfers int64
+	err := r.db.Model(&models.Expense{}).
+		Where("account_id = ? AND user_id = ?", id, userID).
+		Count(&expenses).Error
+	if err != nil {
+		return 0, err
+	}
+	err = r.db.Model(&models.Transfer{}).
+		Where("(from_account_id = ? OR to_account_id = ?) AND user_id = ?", id, id, userID).
+		Count(&transfers).Error
+	return expenses + transfers, err
+}
+
+// GetMovements returns, per account, the sum of its income and incoming
+// transfers minus its expenses and outgoing transfers, up to and including
+// asOf, or over all time when asOf is nil.
+func (r *accountRepository) GetMovements(userID uuid.UUID, asOf *time.Time) (map[uuid.UUID]money.Amount, error) {
+	movements := r.db.Raw(`
+		SELECT account_id, CASE WHEN type = ? THEN amount ELSE -amount END AS delta, date
+		FROM expenses WHERE user_id = ? AND account_id IS NOT NULL
+		UNION ALL
+		SELECT from_account_id, -amount, date FROM transfers WHERE user_id = ?
+		UNION ALL
+		SELECT to_account_id, amount, date FROM transfers WHERE user_id = ?`,
+		models.TransactionTypeIncome, userID, userID, userID,
+	)
+
+	query := r.db.Table("(?) AS movements", movements)
+	if asOf != nil {
+		query = query.Where("date <= ?", asOf)
+	}
+
+	var rows []struct {
+		AccountID uuid.UUID
+		Total     money.Amount
+	}
+	err := query.Select("account_id, COALESCE(SUM(delta), 0) as total").
+		Group("account_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	totals := make(map[uuid.UUID]money.Amount, len(rows))
+	for _, row := range rows {
+		totals[row.AccountID] = row.Total
+	}
+	return totals, nil
+}
+
+// GetHistory lists everything that moved money in or out of the account,
+// newest first.
+func (r *accountRepository) GetHistory(id, userID uuid.UUID, limit, offset int) ([]models.AccountHistoryEntry, int64, error) {
+	history := r.db.Raw(`
+		SELECT id, type AS kind, date, CASE WHEN type = ? THEN amount ELSE -amount END AS amount,
+			category, NULL::uuid AS counterparty_account_id, note, created_at
+		FROM expenses WHERE user_id = ? AND account_id = ?
+		UNION ALL
+		SELECT id, ?, date, -amount, NULL, to_account_id, note, created_at
+		FROM transfers WHERE user_id = ? AND from_account_id = ?
+		UNION ALL
+		SELECT id, ?, date, amount, NULL, from_account_id, note, created_at
+		FROM transfers WHERE user_id = ? AND to_account_id = ?`,
+		models.TransactionTypeIncome, userID, id,
+		models.HistoryKindTransferOut, userID, id,
+		models.HistoryKindTransferIn, userID, id,
+	)
+
+	var total int64
+	query := r.db.Table("(?) AS history", history)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if offset > 0 {
+		query = query.Offset(offset)
+	}
+
+	var entries []models.AccountHistoryEntry
+	err := query.Order("date DESC, created_at DESC").Scan(&entries).Error
+	return entries, total, err
+}
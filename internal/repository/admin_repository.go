@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"time"
+
+	"mamonedz/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// AdminRepository answers platform-wide aggregate queries. It deliberately
+// has no way to read individual expenses.
+type AdminRepository interface {
+	GetPlatformStats(since time.Time) (*models.PlatformStats, error)
+}
+
+type adminRepository struct {
+	db *gorm.DB
+}
+
+func NewAdminRepository(db *gorm.DB) AdminRepository {
+	return &adminRepository{db: db}
+}
+
+// GetPlatformStats counts users and sums expenses across all users. NewUsers
+// and ActiveUsers only consider activity since the given time.
+func (r *adminRepository) GetPlatformStats(since time.Time) (*models.PlatformStats, error) {
+	stats := &models.PlatformStats{}
+
+	var users struct {
+		Total    int64
+		Verified int64
+		Disabled int64
+		Admins   int64
+		New      int64
+	}
+	err := r.db.Model(&models.User{}).
+		Select(`COUNT(*) as total,
+			COUNT(email_verified_at) as verified,
+			COUNT(disabled_at) as disabled,
+			COUNT(*) FILTER (WHERE role = ?) as admins,
+			COUNT(*) FILTER (WHERE created_at >= ?) as new`, models.RoleAdmin, since).
+		Scan(&users).Error
+	if err != nil {
+		return nil, err
+	}
+	stats.TotalUsers = users.Total
+	stats.VerifiedUsers = users.Verified
+	stats.DisabledUsers = users.Disabled
+	stats.Admins = users.Admins
+	stats.NewUsers = users.New
+
+	err = r.db.Model(&models.Session{}).
+		Where("last_seen_at >= ?", since).
+		Distinct("user_id").
+		Count(&stats.ActiveUsers).Error
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.db.Model(&models.Expense{}).
+		Where("type = ?", models.TransactionTypeExpense).
+		Count(&stats.TotalExpenses).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var currencyStats []models.PlatformCurrencyStats
+	err = r.db.Model(&models.Expense{}).
+		Where("type = ?", models.TransactionTypeExpense).
+		Select("currency, COALESCE(SUM(amount), 0) as total, COUNT(*) as count").
+		Group("currency").
+		Order("count DESC").
+		Scan(&currencyStats).Error
+	if err != nil {
+		return nil, err
+	}
+	stats.ByCurrency = currencyStats
+
+	// Only the seeded category names are reported; anything users named
+	// themselves is counted together as custom.
+	var defaults []string
+	for _, c := range models.DefaultCategories {
+		if c.Type == models.TransactionTypeExpense {
+			defaults = append(defaults, c.Name)
+		}
+	}
+	var categoryStats []models.PlatformCategoryStats
+	err = r.db.Model(&models.Expense{}).
+		Where("type = ?", models.TransactionTypeExpense).
+		Select("CASE WHEN category IN ? THEN category ELSE ? END as category, currency, COALESCE(SUM(amount), 0) as total, COUNT(*) as count",
+			defaults, models.PlatformCustomCategory).
+		Group("1, currency").
+		Order("count DESC").
+		Scan(&categoryStats).Error
+	if err != nil {
+		return nil, err
+	}
+	stats.ByCategory = categoryStats
+
+	return stats, nil
+}
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"time"
+
+	"mamonedz/internal/models"
+	"mamonedz/pkg/money"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BudgetRepository interface {
+	Create(budget *models.Budget) (bool, error)
+	GetByID(id, userID uuid.UUID) (*models.Budget, error)
+	GetAllByUser(userID uuid.UUID) ([]models.Budget, error)
+	Update(budget *models.Budget) error
+	Delete(id, userID uuid.UUID) error
+	GetMonthlySpending(userID uuid.UUID, categoryID *uuid.UUID, from, to time.Time) (map[string]money.Amount, error)
+	CreateAlert(alert *models.BudgetAlert) (bool, error)
+	GetAlerts(userID uuid.UUID, limit, offset int) ([]models.BudgetAlert, int64, error)
+}
+
+type budgetRepository struct {
+	db *gorm.DB
+}
+
+func NewBudgetRepository(db *gorm.DB) BudgetRepository {
+	return &budgetRepository{db: db}
+}
+
+// Create inserts the budget unless the user already has one for its
+// category, or an overall one when it has none, and reports whether it did.
+func (r *budgetRepository) Create(budget *models.Budget) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(budget)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *budgetRepository) GetByID(id, userID uuid.UUID) (*models.Budget, error) {
+	var budget models.Budget
+	err := r.db.First(&budget, "id = ? AND user_id = ?", id, userID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &budget, nil
+}
+
+func (r *budgetRepository) GetAllByUser(userID uuid.UUID) ([]models.Budget, error) {
+	var budgets []models.Budget
+	err := r.db.Where("user_id = ?", userID).
+		Order("category_id NULLS FIRST, created_at ASC").
+		Find(&budgets).Error
+	return budgets, err
+}
+
+func (r *budgetRepository) Update(budget *models.Budget) error {
+	return r.db.Save(budget).Error
+}
+
+func (r *budgetRepository) Delete(id, userID uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("budget_id = ? AND user_id = ?", id, userID).Delete(&models.BudgetAlert{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&models.Budget{}, "id = ? AND user_id = ?", id, userID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// GetMonthlySpending sums the expenses in the user's personal ledger from
+// from up to, not including, to per month (keyed YYYY-MM), in their base
+// currency. With a category, only expenses in it or its subcategories count.
+func (r *budgetRepository) GetMonthlySpending(userID uuid.UUID, categoryID *uuid.UUID, from, to time.Time) (map[string]money.Amount, error) {
+	personal := r.db.Model(&models.Ledger{}).Select("id").Where("user_id = ? AND personal", userID)
+	query := r.db.Model(&models.Expense{}).
+		Where("ledger_id IN (?) AND type = ? AND date >= ? AND date < ?", personal, models.TransactionTypeExpense, from, to)
+	if categoryID != nil {
+		query = query.Where("category_id IN (?)", categorySubtree(r.db, userID, "id = ?", *categoryID))
+	}
+
+	var rows []struct {
+		Month string
+		Total money.Amount
+	}
+	err := query.Select("TO_CHAR(date, 'YYYY-MM') as month, COALESCE(SUM(base_amount), 0) as total").
+		Group("month").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	spending := make(map[string]money.Amount, len(rows))
+	for _, row := range rows {
+		spending[row.Month] = row.Total
+	}
+	return spending, nil
+}
+
+// CreateAlert stores the alert unless one already exists for the same
+// budget, month and threshold, and reports whether it was stored.
+func (r *budgetRepository) CreateAlert(alert *models.BudgetAlert) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(alert)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *budgetRepository) GetAlerts(userID uuid.UUID, limit, offset int) ([]models.BudgetAlert, int64, error) {
+	var alerts []models.BudgetAlert
+	var total int64
+
+	query := r.db.Model(&models.BudgetAlert{}).Where("user_id = ?", userID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if offset > 0 {
+		query = query.Offset(offset)
+	}
+
+	err := query.Order("created_at DESC").Find(&alerts).Error
+	return alerts, total, err
+}
@@ -0,0 +1,228 @@
+package repository
+
+import (
+	"mamonedz/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CategoryRepository interface {
+	Create(category *models.Category) error
+	GetByID(id, userID uuid.UUID) (*models.Category, error)
+	GetByName(name, categoryType string, userID uuid.UUID) (*models.Category, error)
+	GetAllByUser(userID uuid.UUID) ([]models.Category, error)
+	GetAllByLedger(ledgerID uuid.UUID) ([]models.Category, error)
+	Update(category *models.Category) error
+	Delete(id, userID uuid.UUID, reassignTo *models.Category) (bool, error)
+	Backfill() error
+}
+
+type categoryRepository struct {
+	db *gorm.DB
+}
+
+func NewCategoryRepository(db *gorm.DB) CategoryRepository {
+	return &categoryRepository{db: db}
+}
+
+func (r *categoryRepository) Create(category *models.Category) error {
+	return r.db.Create(category).Error
+}
+
+func (r *categoryRepository) GetByID(id, userID uuid.UUID) (*models.Category, error) {
+	var category models.Category
+	err := r.db.First(&category, "id = ? AND user_id = ?", id, userID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (r *categoryRepository) GetByName(name, categoryType string, userID uuid.UUID) (*models.Category, error) {
+	var category models.Category
+	err := r.db.First(&category, "name = ? AND type = ? AND user_id = ?", name, categoryType, userID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (r *categoryRepository) GetAllByUser(userID uuid.UUID) ([]models.Category, error) {
+	var categories []models.Category
+	err := r.db.Where("user_id = ?", userID).
+		Order("type ASC, sort_order ASC, name ASC").
+		Find(&categories).Error
+	return categories, err
+}
+
+// GetAllByLedger returns the categories of every member of the ledger,
+// those of the earliest members first.
+func (r *categoryRepository) GetAllByLedger(ledgerID uuid.UUID) ([]models.Category, error) {
+	var categories []models.Category
+	err := r.db.Select("categories.*").
+		Joins("JOIN ledger_members ON ledger_members.user_id = categories.user_id").
+		Where("ledger_members.ledger_id = ?", ledgerID).
+		Order("ledger_members.created_at ASC, categories.type ASC, categories.sort_order ASC, categories.name ASC").
+		Find(&categories).Error
+	return categories, err
+}
+
+// Update saves the category and, when it was renamed, updates the copy of
+// the name on its expenses in the same transaction.
+func (r *categoryRepository) Update(category *models.Category) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(category).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Expense{}).
+			Where("category_id = ? AND user_id = ? AND category <> ?", category.ID, category.UserID, category.Name).
+			Update("category", category.Name).Error
+	})
+}
+
+// countExpenses counts the expenses and recurring templates filed under the
+// category.
+func countExpenses(db *gorm.DB, id, userID uuid.UUID) (int64, error) {
+	var expenses, templates int64
+	err := db.Model(&models.Expense{}).
+		Where("category_id = ? AND user_id = ?", id, userID).
+		Count(&expenses).Error
+	if err != nil {
+		return 0, err
+	}
+	err = db.Model(&models.RecurringExpense{}).
+		Where("category_id = ? AND user_id = ?", id, userID).
+		Count(&templates).Error
+	return expenses + templates, err
+}
+
+// Delete removes the category and its budget after moving its expenses and
+// recurring templates to reassignTo. When reassignTo is nil the category must
+// not have any expenses or recurring templates; Delete then reports false and
+// leaves everything as it was. The check runs in the delete's transaction
+// with the category row locked, so concurrent deletes and moves wait for it.
+// Subcategories move up to the deleted category's parent.
+func (r *categoryRepository) Delete(id, userID uuid.UUID, reassignTo *models.Category) (bool, error) {
+	deleted := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var category models.Category
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&category, "id = ? AND user_id = ?", id, userID).Error
+		if err != nil {
+			return err
+		}
+
+		if reassignTo == nil {
+			count, err := countExpenses(tx, id, userID)
+			if err != nil {
+				return err
+			}
+			if count > 0 {
+				return nil
+			}
+		}
+
+		err = tx.Exec(`
+			UPDATE categories SET parent_id = (SELECT parent_id FROM categories WHERE id = ? AND user_id = ?)
+			WHERE parent_id = ? AND user_id = ?`,
+			id, userID, id, userID,
+		).Error
+		if err != nil {
+			return err
+		}
+
+		if reassignTo != nil {
+			err := tx.Model(&models.Expense{}).
+				Where("category_id = ? AND user_id = ?", id, userID).
+				Updates(map[string]interface{}{
+					"category_id": reassignTo.ID,
+					"category":    reassignTo.Name,
+				}).Error
+			if err != nil {
+				return err
+			}
+			err = tx.Model(&models.RecurringExpense{}).
+				Where("category_id = ? AND user_id = ?", id, userID).
+				Update("category_id", reassignTo.ID).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		err = tx.Exec(
+			"DELETE FROM budget_alerts WHERE budget_id IN (SELECT id FROM budgets WHERE category_id = ? AND user_id = ?)",
+			id, userID,
+		).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("category_id = ? AND user_id = ?", id, userID).Delete(&models.Budget{}).Error; err != nil {
+			return err
+		}
+
+		result := tx.Delete(&models.Category{}, "id = ? AND user_id = ?", id, userID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		deleted = true
+		return nil
+	})
+	return deleted, err
+}
+
+// Backfill brings data from before categories were per-user up to date: it
+// seeds the default categories of each type for users that have none,
+// creates categories for any other names their expenses use, and links
+// those expenses by ID. It is idempotent and cheap once everything has been
+// linked.
+func (r *categoryRepository) Backfill() error {
+	// Names used to be unique per user; they are now unique per user and type.
+	if r.db.Migrator().HasIndex(&models.Category{}, "idx_category_user_name") {
+		if err := r.db.Migrator().DropIndex(&models.Category{}, "idx_category_user_name"); err != nil {
+			return err
+		}
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, categoryType := range []string{models.TransactionTypeExpense, models.TransactionTypeIncome} {
+			var userIDs []uuid.UUID
+			err := tx.Model(&models.User{}).
+				Where("NOT EXISTS (SELECT 1 FROM categories WHERE categories.user_id = users.id AND categories.type = ?)", categoryType).
+				Pluck("id", &userIDs).Error
+			if err != nil {
+				return err
+			}
+			for _, userID := range userIDs {
+				if err := tx.Create(models.NewDefaultCategories(userID, categoryType)).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		err := tx.Exec(`
+			INSERT INTO categories (user_id, type, name, sort_order)
+			SELECT DISTINCT user_id, type, category, ?
+			FROM expenses
+			WHERE category_id IS NULL
+			ON CONFLICT (user_id, type, name) DO NOTHING`,
+			len(models.DefaultCategories),
+		).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Exec(`
+			UPDATE expenses SET category_id = categories.id
+			FROM categories
+			WHERE expenses.category_id IS NULL
+				AND categories.user_id = expenses.user_id
+				AND categories.type = expenses.type
+				AND categories.name = expenses.category`,
+		).Error
+	})
+}
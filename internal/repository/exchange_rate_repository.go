@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"mamonedz/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ExchangeRateRepository interface {
+	Upsert(rates []models.ExchangeRate) error
+	GetAll(filter *models.ExchangeRateFilter) ([]models.ExchangeRate, int64, error)
+}
+
+type exchangeRateRepository struct {
+	db *gorm.DB
+}
+
+func NewExchangeRateRepository(db *gorm.DB) ExchangeRateRepository {
+	return &exchangeRateRepository{db: db}
+}
+
+// Upsert stores the rates, replacing any existing rate for the same pair and
+// date.
+func (r *exchangeRateRepository) Upsert(rates []models.ExchangeRate) error {
+	if len(rates) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "base_currency"}, {Name: "quote_currency"}, {Name: "date"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"rate":       gorm.Expr("excluded.rate"),
+			"updated_at": gorm.Expr("CURRENT_TIMESTAMP"),
+		}),
+	}).CreateInBatches(rates, 500).Error
+}
+
+func (r *exchangeRateRepository) GetAll(filter *models.ExchangeRateFilter) ([]models.ExchangeRate, int64, error) {
+	var rates []models.ExchangeRate
+	var total int64
+
+	query := r.db.Model(&models.ExchangeRate{})
+	if filter.BaseCurrency != "" {
+		query = query.Where("base_currency = ?", filter.BaseCurrency)
+	}
+	if filter.QuoteCurrency != "" {
+		query = query.Where("quote_currency = ?", filter.QuoteCurrency)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+
+	err := query.Order("date DESC, base_currency, quote_currency").Find(&rates).Error
+	return rates, total, err
+}
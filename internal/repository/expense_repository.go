@@ -4,18 +4,28 @@ import (
 	"time"
 
 	"mamonedz/internal/models"
+	"mamonedz/pkg/money"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ExpenseRepository limits reads to the ledgers the requesting user is a
+// member of, and updates and deletes to those where their role is in
+// models.LedgerWriteRoles. Create trusts the caller to have checked.
 type ExpenseRepository interface {
 	Create(expense *models.Expense) error
 	GetByID(id, userID uuid.UUID) (*models.Expense, error)
 	GetAll(filter *models.ExpenseFilter) ([]models.Expense, int64, error)
-	Update(expense *models.Expense) error
+	Update(expense *models.Expense, userID uuid.UUID) error
 	Delete(id, userID uuid.UUID) error
-	GetStats(userID uuid.UUID, startDate, endDate *time.Time) (*models.ExpenseStats, error)
+	GetStats(ledgerID, userID uuid.UUID, txType string, startDate, endDate *time.Time) (*models.ExpenseStats, error)
+	GetTypeTotals(ledgerID, userID uuid.UUID, startDate, endDate *time.Time) (map[string]money.Amount, error)
+	GetTagStats(ledgerID, userID uuid.UUID, txType string, startDate, endDate *time.Time) ([]models.TagStats, error)
+	ConvertByLedger(ledgerID uuid.UUID) error
+	ConvertPair(currencyA, currencyB string, from time.Time) error
+	ConvertMissing() error
 }
 
 type expenseRepository struct {
@@ -26,13 +36,22 @@ func NewExpenseRepository(db *gorm.DB) ExpenseRepository {
 	return &expenseRepository{db: db}
 }
 
+// Create inserts the expense, links it to expense.Tags, which must already
+// exist, and fills in its converted amount.
 func (r *expenseRepository) Create(expense *models.Expense) error {
-	return r.db.Create(expense).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Tags.*").Create(expense).Error; err != nil {
+			return err
+		}
+		return convertExpense(tx, expense)
+	})
 }
 
 func (r *expenseRepository) GetByID(id, userID uuid.UUID) (*models.Expense, error) {
 	var expense models.Expense
-	err := r.db.First(&expense, "id = ? AND user_id = ?", id, userID).Error
+	err := r.db.Preload("Tags", orderTags).
+		Where("ledger_id IN (?)", memberLedgers(r.db, userID)).
+		First(&expense, "id = ?", id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -43,7 +62,13 @@ func (r *expenseRepository) GetAll(filter *models.ExpenseFilter) ([]models.Expen
 	var expenses []models.Expense
 	var total int64
 
-	query := r.db.Model(&models.Expense{}).Where("user_id = ?", filter.UserID)
+	query := r.db.Model(&models.Expense{}).Where("ledger_id IN (?)", memberLedgers(r.db, filter.UserID))
+	if filter.LedgerID != nil {
+		query = query.Where("ledger_id = ?", *filter.LedgerID)
+	}
+	if filter.Type != "" && filter.Type != models.TransactionTypeAll {
+		query = query.Where("type = ?", filter.Type)
+	}
 
 	if filter.StartDate != nil {
 		query = query.Where("date >= ?", filter.StartDate)
@@ -51,8 +76,32 @@ func (r *expenseRepository) GetAll(filter *models.ExpenseFilter) ([]models.Expen
 	if filter.EndDate != nil {
 		query = query.Where("date <= ?", filter.EndDate)
 	}
+	var users interface{} = filter.UserID
+	if filter.LedgerID != nil {
+		users = r.db.Model(&models.LedgerMember{}).Select("user_id").Where("ledger_id = ?", *filter.LedgerID)
+	}
 	if filter.Category != nil && *filter.Category != "" {
-		query = query.Where("category = ?", *filter.Category)
+		query = query.Where("category_id IN (?)", categorySubtree(r.db, users, "name = ?", *filter.Category))
+	}
+	if filter.CategoryID != nil {
+		query = query.Where("category_id IN (?)", categorySubtree(r.db, users, "id = ?", *filter.CategoryID))
+	}
+	if filter.AccountID != nil {
+		query = query.Where("account_id = ?", *filter.AccountID)
+	}
+	if filter.RecurringID != nil {
+		query = query.Where("recurring_id = ?", *filter.RecurringID)
+	}
+	if len(filter.Tags) > 0 {
+		tagged := r.db.Table("expense_tags").
+			Select("expense_tags.expense_id").
+			Joins("JOIN tags ON tags.id = expense_tags.tag_id").
+			Where("tags.name IN ?", filter.Tags)
+		if filter.TagMatch == models.TagMatchAll {
+			tagged = tagged.Group("expense_tags.expense_id").
+				Having("COUNT(DISTINCT tags.name) = ?", len(filter.Tags))
+		}
+		query = query.Where("id IN (?)", tagged)
 	}
 
 	query.Count(&total)
@@ -64,22 +113,67 @@ func (r *expenseRepository) GetAll(filter *models.ExpenseFilter) ([]models.Expen
 		query = query.Offset(filter.Offset)
 	}
 
-	err := query.Order("date DESC, created_at DESC").Find(&expenses).Error
+	err := query.Preload("Tags", orderTags).Order("date DESC, created_at DESC").Find(&expenses).Error
 	return expenses, total, err
 }
 
-func (r *expenseRepository) Update(expense *models.Expense) error {
-	return r.db.Save(expense).Error
+// Update saves the expense, replaces its tag links with expense.Tags and
+// converts its amount again.
+func (r *expenseRepository) Update(expense *models.Expense, userID uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(expense).
+			Where("ledger_id IN (?)", memberLedgers(tx, userID, models.LedgerWriteRoles...)).
+			Select("*").
+			Omit(clause.Associations).
+			Updates(expense)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if err := tx.Model(expense).Omit("Tags.*").Association("Tags").Replace(expense.Tags); err != nil {
+			return err
+		}
+		return convertExpense(tx, expense)
+	})
 }
 
+// Delete removes the expense. When it records a transfer fee, the transfer
+// stays but loses the link to it.
 func (r *expenseRepository) Delete(id, userID uuid.UUID) error {
-	return r.db.Delete(&models.Expense{}, "id = ? AND user_id = ?", id, userID).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		writable := memberLedgers(tx, userID, models.LedgerWriteRoles...)
+		err := tx.Exec(
+			"DELETE FROM expense_tags WHERE expense_id IN (SELECT id FROM expenses WHERE id = ? AND ledger_id IN (?))",
+			id, writable,
+		).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&models.Transfer{}).
+			Where("fee_expense_id = ?", id).
+			Update("fee_expense_id", nil).Error
+		if err != nil {
+			return err
+		}
+		result := tx.Delete(&models.Expense{}, "id = ? AND ledger_id IN (?)", id, writable)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
 }
 
-func (r *expenseRepository) GetStats(userID uuid.UUID, startDate, endDate *time.Time) (*models.ExpenseStats, error) {
+// GetStats summarizes the ledger's transactions of one type in the date
+// range, in the ledger's base currency.
+func (r *expenseRepository) GetStats(ledgerID, userID uuid.UUID, txType string, startDate, endDate *time.Time) (*models.ExpenseStats, error) {
 	stats := &models.ExpenseStats{}
 
-	query := r.db.Model(&models.Expense{}).Where("user_id = ?", userID)
+	query := r.db.Model(&models.Expense{}).Where("type = ?", txType).Scopes(inLedger(r.db, ledgerID, userID))
 	if startDate != nil {
 		query = query.Where("date >= ?", startDate)
 	}
@@ -88,36 +182,38 @@ func (r *expenseRepository) GetStats(userID uuid.UUID, startDate, endDate *time.
 	}
 
 	var result struct {
-		Total float64
-		Count int
+		Total       money.Amount
+		Count       int
+		Unconverted int
 	}
-	query.Select("COALESCE(SUM(amount), 0) as total, COUNT(*) as count").Scan(&result)
+	query.Select("COALESCE(SUM(base_amount), 0) as total, COUNT(*) as count, COUNT(*) FILTER (WHERE base_amount IS NULL) as unconverted").Scan(&result)
 	stats.Total = result.Total
 	stats.Count = result.Count
+	stats.Unconverted = result.Unconverted
 
 	var categoryStats []models.CategoryStats
-	catQuery := r.db.Model(&models.Expense{}).Where("user_id = ?", userID)
+	catQuery := r.db.Model(&models.Expense{}).Where("type = ?", txType).Scopes(inLedger(r.db, ledgerID, userID))
 	if startDate != nil {
 		catQuery = catQuery.Where("date >= ?", startDate)
 	}
 	if endDate != nil {
 		catQuery = catQuery.Where("date <= ?", endDate)
 	}
-	catQuery.Select("category, COALESCE(SUM(amount), 0) as total, COUNT(*) as count").
-		Group("category").
+	catQuery.Select("category_id, category, COALESCE(SUM(base_amount), 0) as total, COUNT(*) as count").
+		Group("category_id, category").
 		Order("total DESC").
 		Scan(&categoryStats)
 	stats.ByCategory = categoryStats
 
 	var dailyTrend []models.DailyTrend
-	trendQuery := r.db.Model(&models.Expense{}).Where("user_id = ?", userID)
+	trendQuery := r.db.Model(&models.Expense{}).Where("type = ?", txType).Scopes(inLedger(r.db, ledgerID, userID))
 	if startDate != nil {
 		trendQuery = trendQuery.Where("date >= ?", startDate)
 	}
 	if endDate != nil {
 		trendQuery = trendQuery.Where("date <= ?", endDate)
 	}
-	trendQuery.Select("TO_CHAR(date, 'YYYY-MM-DD') as date, COALESCE(SUM(amount), 0) as total").
+	trendQuery.Select("TO_CHAR(date, 'YYYY-MM-DD') as date, COALESCE(SUM(base_amount), 0) as total").
 		Group("date").
 		Order("date ASC").
 		Scan(&dailyTrend)
@@ -125,3 +221,153 @@ func (r *expenseRepository) GetStats(userID uuid.UUID, startDate, endDate *time.
 
 	return stats, nil
 }
+
+// GetTypeTotals sums the ledger's transactions in the date range per type,
+// in its base currency.
+func (r *expenseRepository) GetTypeTotals(ledgerID, userID uuid.UUID, startDate, endDate *time.Time) (map[string]money.Amount, error) {
+	query := r.db.Model(&models.Expense{}).Scopes(inLedger(r.db, ledgerID, userID))
+	if startDate != nil {
+		query = query.Where("date >= ?", startDate)
+	}
+	if endDate != nil {
+		query = query.Where("date <= ?", endDate)
+	}
+
+	var rows []struct {
+		Type  string
+		Total money.Amount
+	}
+	err := query.Select("type, COALESCE(SUM(base_amount), 0) as total").
+		Group("type").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	totals := make(map[string]money.Amount, len(rows))
+	for _, row := range rows {
+		totals[row.Type] = row.Total
+	}
+	return totals, nil
+}
+
+// GetTagStats sums transactions of one type per tag. A transaction with
+// several tags counts towards each of them, so the totals can add up to more
+// than the overall total.
+func (r *expenseRepository) GetTagStats(ledgerID, userID uuid.UUID, txType string, startDate, endDate *time.Time) ([]models.TagStats, error) {
+	query := r.db.Model(&models.Expense{}).
+		Joins("JOIN expense_tags ON expense_tags.expense_id = expenses.id").
+		Joins("JOIN tags ON tags.id = expense_tags.tag_id").
+		Where("expenses.type = ?", txType).
+		Scopes(inLedger(r.db, ledgerID, userID))
+	if startDate != nil {
+		query = query.Where("expenses.date >= ?", startDate)
+	}
+	if endDate != nil {
+		query = query.Where("expenses.date <= ?", endDate)
+	}
+
+	var tagStats []models.TagStats
+	err := query.Select("tags.id as tag_id, tags.name as tag, COALESCE(SUM(expenses.base_amount), 0) as total, COUNT(*) as count").
+		Group("tags.id, tags.name").
+		Order("total DESC").
+		Scan(&tagStats).Error
+	return tagStats, err
+}
+
+// ConvertByLedger converts all of the ledger's transactions again, after its
+// base currency changed.
+func (r *expenseRepository) ConvertByLedger(ledgerID uuid.UUID) error {
+	return convertExpenses(r.db, "e.ledger_id = ?", ledgerID)
+}
+
+// ConvertPair converts again the transactions dated from on that are
+// recorded in one of the two currencies in a ledger using the other, after
+// rates for the pair changed. Rates in either direction are used, so the
+// order of the currencies does not matter.
+func (r *expenseRepository) ConvertPair(currencyA, currencyB string, from time.Time) error {
+	return convertExpenses(r.db,
+		"e.date >= ? AND ((e.currency = ? AND l.base_currency = ?) OR (e.currency = ? AND l.base_currency = ?))",
+		from, currencyA, currencyB, currencyB, currencyA,
+	)
+}
+
+// ConvertMissing converts the transactions that were never converted, which
+// are those recorded before conversion existed. Every later transaction is
+// converted when it is saved, even when no rate is known yet.
+func (r *expenseRepository) ConvertMissing() error {
+	return convertExpenses(r.db, "e.base_currency IS NULL")
+}
+
+// convertExpenses sets the converted amount of the expenses matching
+// condition, which can refer to the expense as e and its ledger as l. The rate
+// used is the latest one on or before the expense date, taken directly or as
+// the inverse of the opposite pair; without one the converted amount is NULL.
+func convertExpenses(db *gorm.DB, condition string, args ...interface{}) error {
+	return db.Exec(`
+		WITH conv AS (
+			SELECT e.id, l.base_currency,
+				CASE WHEN e.currency = l.base_currency THEN 1 ELSE (
+					SELECT r.rate FROM (
+						SELECT rate, date FROM exchange_rates
+						WHERE base_currency = e.currency AND quote_currency = l.base_currency AND date <= e.date
+						UNION ALL
+						SELECT 1 / rate, date FROM exchange_rates
+						WHERE base_currency = l.base_currency AND quote_currency = e.currency AND date <= e.date
+					) r ORDER BY r.date DESC LIMIT 1
+				) END AS rate
+			FROM expenses e JOIN ledgers l ON l.id = e.ledger_id
+			WHERE `+condition+`
+		)
+		UPDATE expenses
+		SET base_currency = conv.base_currency,
+			exchange_rate = conv.rate,
+			base_amount = ROUND(expenses.amount * conv.rate, 2)
+		FROM conv WHERE expenses.id = conv.id`,
+		args...,
+	).Error
+}
+
+// convertExpense converts a single expense and reads the result back into it.
+func convertExpense(tx *gorm.DB, expense *models.Expense) error {
+	if err := convertExpenses(tx, "e.id = ?", expense.ID); err != nil {
+		return err
+	}
+	return tx.Model(&models.Expense{}).
+		Select("base_amount, base_currency, exchange_rate").
+		Where("id = ?", expense.ID).
+		Scan(expense).Error
+}
+
+// inLedger limits a query on expenses to the ledger, provided the user is a
+// member of it.
+func inLedger(db *gorm.DB, ledgerID, userID uuid.UUID) func(*gorm.DB) *gorm.DB {
+	return func(query *gorm.DB) *gorm.DB {
+		return query.Where("expenses.ledger_id = ? AND expenses.ledger_id IN (?)", ledgerID, memberLedgers(db, userID))
+	}
+}
+
+func orderTags(db *gorm.DB) *gorm.DB {
+	return db.Order("tags.name ASC")
+}
+
+// categorySubtree selects the IDs of the categories matching condition and
+// of all their descendants. users is a user ID or a query selecting several;
+// categories of any of them with the same type and name as a match count as
+// matches too, so that a filter on a shared ledger covers what every member
+// filed under that name. UNION rather than UNION ALL keeps the query from
+// running forever should concurrent moves ever leave a cycle behind.
+func categorySubtree(db *gorm.DB, users interface{}, condition string, value interface{}) *gorm.DB {
+	return db.Raw(`
+		WITH RECURSIVE tree AS (
+			SELECT id FROM categories
+			WHERE user_id IN (?) AND (type, name) IN (
+				SELECT type, name FROM categories WHERE user_id IN (?) AND `+condition+`
+			)
+			UNION
+			SELECT categories.id FROM categories JOIN tree ON categories.parent_id = tree.id
+		)
+		SELECT id FROM tree`,
+		users, users, value,
+	)
+}
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"mamonedz/internal/models"
+	"mamonedz/pkg/money"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GoalRepository interface {
+	Create(goal *models.SavingsGoal) error
+	GetByID(id, userID uuid.UUID) (*models.SavingsGoal, error)
+	GetAllByUser(userID uuid.UUID) ([]models.SavingsGoal, error)
+	Update(goal *models.SavingsGoal) error
+	Delete(id, userID uuid.UUID) error
+	GetSaved(userID uuid.UUID) (map[uuid.UUID]money.Amount, error)
+	GetMonthlyContributions(goalID, userID uuid.UUID) (map[string]money.Amount, error)
+	CreateContribution(contribution *models.GoalContribution) (bool, error)
+	GetContributions(goalID, userID uuid.UUID, limit, offset int) ([]models.GoalContribution, int64, error)
+	DeleteContribution(id, goalID, userID uuid.UUID) error
+}
+
+type goalRepository struct {
+	db *gorm.DB
+}
+
+func NewGoalRepository(db *gorm.DB) GoalRepository {
+	return &goalRepository{db: db}
+}
+
+func (r *goalRepository) Create(goal *models.SavingsGoal) error {
+	return r.db.Create(goal).Error
+}
+
+func (r *goalRepository) GetByID(id, userID uuid.UUID) (*models.SavingsGoal, error) {
+	var goal models.SavingsGoal
+	err := r.db.First(&goal, "id = ? AND user_id = ?", id, userID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &goal, nil
+}
+
+func (r *goalRepository) GetAllByUser(userID uuid.UUID) ([]models.SavingsGoal, error) {
+	var goals []models.SavingsGoal
+	err := r.db.Where("user_id = ?", userID).
+		Order("target_date ASC NULLS LAST, created_at ASC").
+		Find(&goals).Error
+	return goals, err
+}
+
+func (r *goalRepository) Update(goal *models.SavingsGoal) error {
+	return r.db.Save(goal).Error
+}
+
+// Delete removes the goal with its contributions. Linked transfers stay.
+func (r *goalRepository) Delete(id, userID uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("goal_id = ? AND user_id = ?", id, userID).Delete(&models.GoalContribution{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&models.SavingsGoal{}, "id = ? AND user_id = ?", id, userID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// GetSaved sums the contributions to each of the user's goals. Goals without
+// contributions are missing from the map.
+func (r *goalRepository) GetSaved(userID uuid.UUID) (map[uuid.UUID]money.Amount, error) {
+	var rows []struct {
+		GoalID uuid.UUID
+		Total  money.Amount
+	}
+	err := r.db.Model(&models.GoalContribution{}).
+		Select("goal_id, COALESCE(SUM(amount), 0) as total").
+		Where("user_id = ?", userID).
+		Group("goal_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	saved := make(map[uuid.UUID]money.Amount, len(rows))
+	for _, row := range rows {
+		saved[row.GoalID] = row.Total
+	}
+	return saved, nil
+}
+
+// GetMonthlyContributions sums the goal's contributions per month, keyed
+// YYYY-MM.
+func (r *goalRepository) GetMonthlyContributions(goalID, userID uuid.UUID) (map[string]money.Amount, error) {
+	var rows []struct {
+		Month string
+		Total money.Amount
+	}
+	err := r.db.Model(&models.GoalContribution{}).
+		Select("TO_CHAR(date, 'YYYY-MM') as month, COALESCE(SUM(amount), 0) as total").
+		Where("goal_id = ? AND user_id = ?", goalID, userID).
+		Group("month").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	totals := make(map[string]money.Amount, len(rows))
+	for _, row := range rows {
+		totals[row.Month] = row.Total
+	}
+	return totals, nil
+}
+
+// CreateContribution inserts the contribution unless its transfer is already
+// linked to a contribution, and reports whether it did.
+func (r *goalRepository) CreateContribution(contribution *models.GoalContribution) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(contribution)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *goalRepository) GetContributions(goalID, userID uuid.UUID, limit, offset int) ([]models.GoalContribution, int64, error) {
+	var contributions []models.GoalContribution
+	var total int64
+
+	query := r.db.Model(&models.GoalContribution{}).Where("goal_id = ? AND user_id = ?", goalID, userID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if offset > 0 {
+		query = query.Offset(offset)
+	}
+
+	err := query.Order("date DESC, created_at DESC").Find(&contributions).Error
+	return contributions, total, err
+}
+
+func (r *goalRepository) DeleteContribution(id, goalID, userID uuid.UUID) error {
+	result := r.db.Delete(&models.GoalContribution{}, "id = ? AND goal_id = ? AND user_id = ?", id, goalID, userID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
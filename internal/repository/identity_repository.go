@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"time"
+
+	"mamonedz/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IdentityRepository interface {
+	Create(identity *models.ExternalIdentity) error
+	GetByProviderSubject(provider, subject string) (*models.ExternalIdentity, error)
+	CreateState(state *models.OIDCState) error
+	ConsumeState(state, provider string) (*models.OIDCState, error)
+}
+
+type identityRepository struct {
+	db *gorm.DB
+}
+
+func NewIdentityRepository(db *gorm.DB) IdentityRepository {
+	return &identityRepository{db: db}
+}
+
+func (r *identityRepository) Create(identity *models.ExternalIdentity) error {
+	return r.db.Create(identity).Error
+}
+
+func (r *identityRepository) GetByProviderSubject(provider, subject string) (*models.ExternalIdentity, error) {
+	var identity models.ExternalIdentity
+	err := r.db.First(&identity, "provider = ? AND subject = ?", provider, subject).Error
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// CreateState stores a pending authorization request and clears out expired
+// ones.
+func (r *identityRepository) CreateState(state *models.OIDCState) error {
+	if err := r.db.Delete(&models.OIDCState{}, "expires_at < ?", time.Now()).Error; err != nil {
+		return err
+	}
+	return r.db.Create(state).Error
+}
+
+// ConsumeState deletes and returns an unexpired state, so each one can only
+// complete a single login.
+func (r *identityRepository) ConsumeState(state, provider string) (*models.OIDCState, error) {
+	var states []models.OIDCState
+	err := r.db.Clauses(clause.Returning{}).
+		Where("state = ? AND provider = ? AND expires_at > ?", state, provider, time.Now()).
+		Delete(&states).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(states) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &states[0], nil
+}
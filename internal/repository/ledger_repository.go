@@ -0,0 +1,277 @@
+package repository
+
+import (
+	"time"
+
+	"mamonedz/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LedgerRepository interface {
+	Create(ledger *models.Ledger) error
+	GetByID(id, userID uuid.UUID) (*models.LedgerWithRole, error)
+	GetPersonal(userID uuid.UUID) (*models.Ledger, error)
+	GetAllByUser(userID uuid.UUID) ([]models.LedgerWithRole, error)
+	Update(ledger *models.Ledger) error
+	Delete(id uuid.UUID) error
+	CountExpenses(id uuid.UUID) (int64, error)
+	AssignPersonal() (int64, error)
+	GetMember(ledgerID, userID uuid.UUID) (*models.LedgerMember, error)
+	GetMembers(ledgerID uuid.UUID) ([]models.LedgerMemberInfo, error)
+	SetRole(ledgerID, userID uuid.UUID, role string) error
+	RemoveMember(ledgerID, userID uuid.UUID) error
+	SaveInvitation(invitation *models.LedgerInvitation) error
+	GetInvitation(id uuid.UUID) (*models.LedgerInvitation, error)
+	GetInvitations(ledgerID uuid.UUID) ([]models.LedgerInvitation, error)
+	GetInvitationsByEmail(email string) ([]models.PendingInvitation, error)
+	AcceptInvitation(invitation *models.LedgerInvitation, userID uuid.UUID) error
+	DeleteInvitation(id uuid.UUID) error
+}
+
+type ledgerRepository struct {
+	db *gorm.DB
+}
+
+func NewLedgerRepository(db *gorm.DB) LedgerRepository {
+	return &ledgerRepository{db: db}
+}
+
+// Create inserts the ledger and makes its owner a member.
+func (r *ledgerRepository) Create(ledger *models.Ledger) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return createLedger(tx, ledger)
+	})
+}
+
+// GetByID returns the ledger if the user is a member of it.
+func (r *ledgerRepository) GetByID(id, userID uuid.UUID) (*models.LedgerWithRole, error) {
+	var ledger models.LedgerWithRole
+	err := r.db.Model(&models.Ledger{}).
+		Select("ledgers.*, ledger_members.role").
+		Joins("JOIN ledger_members ON ledger_members.ledger_id = ledgers.id").
+		Where("ledgers.id = ? AND ledger_members.user_id = ?", id, userID).
+		Take(&ledger).Error
+	if err != nil {
+		return nil, err
+	}
+	return &ledger, nil
+}
+
+func (r *ledgerRepository) GetPersonal(userID uuid.UUID) (*models.Ledger, error) {
+	var ledger models.Ledger
+	err := r.db.First(&ledger, "user_id = ? AND personal", userID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &ledger, nil
+}
+
+// GetAllByUser lists the ledgers the user is a member of, the personal one
+// first.
+func (r *ledgerRepository) GetAllByUser(userID uuid.UUID) ([]models.LedgerWithRole, error) {
+	var ledgers []models.LedgerWithRole
+	err := r.db.Model(&models.Ledger{}).
+		Select("ledgers.*, ledger_members.role").
+		Joins("JOIN ledger_members ON ledger_members.ledger_id = ledgers.id").
+		Where("ledger_members.user_id = ?", userID).
+		Order("ledgers.personal DESC, ledgers.name ASC").
+		Find(&ledgers).Error
+	return ledgers, err
+}
+
+func (r *ledgerRepository) Update(ledger *models.Ledger) error {
+	return r.db.Save(ledger).Error
+}
+
+// Delete removes the ledger with its members and pending invitations. It
+// must not have any transactions left.
+func (r *ledgerRepository) Delete(id uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("ledger_id = ?", id).Delete(&models.LedgerInvitation{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("ledger_id = ?", id).Delete(&models.LedgerMember{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&models.Ledger{}, "id = ?", id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+func (r *ledgerRepository) CountExpenses(id uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Expense{}).Where("ledger_id = ?", id).Count(&count).Error
+	return count, err
+}
+
+// AssignPersonal gives every user without one a personal ledger and moves
+// transactions that predate ledgers into their recorder's personal ledger,
+// paid by the recorder. It returns how many transactions were moved.
+func (r *ledgerRepository) AssignPersonal() (int64, error) {
+	var moved int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`
+			INSERT INTO ledgers (user_id, name, base_currency, personal)
+			SELECT id, ?, base_currency, true FROM users
+			WHERE NOT EXISTS (SELECT 1 FROM ledgers WHERE ledgers.user_id = users.id AND ledgers.personal)`,
+			models.PersonalLedgerName,
+		).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Exec(`
+			INSERT INTO ledger_members (ledger_id, user_id, role)
+			SELECT id, user_id, ? FROM ledgers
+			WHERE personal AND NOT EXISTS (
+				SELECT 1 FROM ledger_members WHERE ledger_members.ledger_id = ledgers.id
+			)`,
+			models.LedgerRoleOwner,
+		).Error
+		if err != nil {
+			return err
+		}
+		result := tx.Exec(`
+			UPDATE expenses SET ledger_id = ledgers.id, paid_by = expenses.user_id
+			FROM ledgers
+			WHERE expenses.ledger_id IS NULL AND ledgers.user_id = expenses.user_id AND ledgers.personal`)
+		moved = result.RowsAffected
+		return result.Error
+	})
+	return moved, err
+}
+
+func (r *ledgerRepository) GetMember(ledgerID, userID uuid.UUID) (*models.LedgerMember, error) {
+	var member models.LedgerMember
+	err := r.db.First(&member, "ledger_id = ? AND user_id = ?", ledgerID, userID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+func (r *ledgerRepository) GetMembers(ledgerID uuid.UUID) ([]models.LedgerMemberInfo, error) {
+	var members []models.LedgerMemberInfo
+	err := r.db.Model(&models.LedgerMember{}).
+		Select("ledger_members.user_id, users.name, users.email, ledger_members.role, ledger_members.created_at").
+		Joins("JOIN users ON users.id = ledger_members.user_id").
+		Where("ledger_members.ledger_id = ?", ledgerID).
+		Order("ledger_members.created_at ASC").
+		Scan(&members).Error
+	return members, err
+}
+
+func (r *ledgerRepository) SetRole(ledgerID, userID uuid.UUID, role string) error {
+	result := r.db.Model(&models.LedgerMember{}).
+		Where("ledger_id = ? AND user_id = ?", ledgerID, userID).
+		Update("role", role)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *ledgerRepository) RemoveMember(ledgerID, userID uuid.UUID) error {
+	result := r.db.Delete(&models.LedgerMember{}, "ledger_id = ? AND user_id = ?", ledgerID, userID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// SaveInvitation stores the invitation, replacing a pending one for the same
+// ledger and email.
+func (r *ledgerRepository) SaveInvitation(invitation *models.LedgerInvitation) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "ledger_id"}, {Name: "email"}},
+		DoUpdates: clause.AssignmentColumns([]string{"role", "invited_by", "expires_at", "created_at"}),
+	}).Create(invitation).Error
+}
+
+// GetInvitation returns the invitation unless it has expired.
+func (r *ledgerRepository) GetInvitation(id uuid.UUID) (*models.LedgerInvitation, error) {
+	var invitation models.LedgerInvitation
+	err := r.db.First(&invitation, "id = ? AND expires_at > ?", id, time.Now()).Error
+	if err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+func (r *ledgerRepository) GetInvitations(ledgerID uuid.UUID) ([]models.LedgerInvitation, error) {
+	var invitations []models.LedgerInvitation
+	err := r.db.Where("ledger_id = ? AND expires_at > ?", ledgerID, time.Now()).
+		Order("created_at DESC").
+		Find(&invitations).Error
+	return invitations, err
+}
+
+func (r *ledgerRepository) GetInvitationsByEmail(email string) ([]models.PendingInvitation, error) {
+	var invitations []models.PendingInvitation
+	err := r.db.Model(&models.LedgerInvitation{}).
+		Select("ledger_invitations.*, ledgers.name as ledger_name").
+		Joins("JOIN ledgers ON ledgers.id = ledger_invitations.ledger_id").
+		Where("ledger_invitations.email = ? AND ledger_invitations.expires_at > ?", email, time.Now()).
+		Order("ledger_invitations.created_at DESC").
+		Find(&invitations).Error
+	return invitations, err
+}
+
+// AcceptInvitation makes the user a member with the invitation's role and
+// removes the invitation.
+func (r *ledgerRepository) AcceptInvitation(invitation *models.LedgerInvitation, userID uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		member := &models.LedgerMember{LedgerID: invitation.LedgerID, UserID: userID, Role: invitation.Role}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(member).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.LedgerInvitation{}, "id = ?", invitation.ID).Error
+	})
+}
+
+func (r *ledgerRepository) DeleteInvitation(id uuid.UUID) error {
+	result := r.db.Delete(&models.LedgerInvitation{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// createLedger inserts the ledger and its owner's membership within tx.
+func createLedger(tx *gorm.DB, ledger *models.Ledger) error {
+	if err := tx.Create(ledger).Error; err != nil {
+		return err
+	}
+	return tx.Create(&models.LedgerMember{
+		LedgerID: ledger.ID,
+		UserID:   ledger.UserID,
+		Role:     models.LedgerRoleOwner,
+	}).Error
+}
+
+// memberLedgers selects the IDs of the ledgers the user is a member of, with
+// one of roles if any are given.
+func memberLedgers(db *gorm.DB, userID uuid.UUID, roles ...string) *gorm.DB {
+	query := db.Model(&models.LedgerMember{}).Select("ledger_id").Where("user_id = ?", userID)
+	if len(roles) > 0 {
+		query = query.Where("role IN ?", roles)
+	}
+	return query
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"sync"
+	"time"
+
+	"mamonedz/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// LoginAttemptStore keeps failed login counters. The in-memory store is
+// enough for a single instance; use the Postgres store when several replicas
+// must share the counters.
+type LoginAttemptStore interface {
+	// Get returns the counter for key, or nil when there is none.
+	Get(key string) (*models.LoginAttempt, error)
+	// RecordFailure increments the counter, starting a new one when the
+	// previous window has elapsed.
+	RecordFailure(key string, window time.Duration) (*models.LoginAttempt, error)
+	Lock(key string, until time.Time) error
+	Reset(key string) error
+}
+
+type memoryLoginAttemptStore struct {
+	mu       sync.Mutex
+	attempts map[string]*models.LoginAttempt
+	// windows remembers the window each counter was recorded with, since
+	// different callers count over different periods.
+	windows map[string]time.Duration
+}
+
+func NewMemoryLoginAttemptStore() LoginAttemptStore {
+	return &memoryLoginAttemptStore{
+		attempts: make(map[string]*models.LoginAttempt),
+		windows:  make(map[string]time.Duration),
+	}
+}
+
+func (s *memoryLoginAttemptStore) Get(key string) (*models.LoginAttempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempt, ok := s.attempts[key]
+	if !ok {
+		return nil, nil
+	}
+	copied := *attempt
+	return &copied, nil
+}
+
+func (s *memoryLoginAttemptStore) RecordFailure(key string, window time.Duration) (*models.LoginAttempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.prune(now)
+
+	attempt, ok := s.attempts[key]
+	if !ok || now.Sub(attempt.FirstFailureAt) > window {
+		attempt = &models.LoginAttempt{Key: key, FirstFailureAt: now}
+		s.attempts[key] = attempt
+	}
+	s.windows[key] = window
+	attempt.Failures++
+	attempt.LastFailureAt = now
+
+	copied := *attempt
+	return &copied, nil
+}
+
+func (s *memoryLoginAttemptStore) Lock(key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if attempt, ok := s.attempts[key]; ok {
+		attempt.LockedUntil = &until
+	}
+	return nil
+}
+
+func (s *memoryLoginAttemptStore) Reset(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.attempts, key)
+	delete(s.windows, key)
+	return nil
+}
+
+// prune drops counters that can no longer affect a login decision so the map
+// does not grow without bound. Callers must hold s.mu.
+func (s *memoryLoginAttemptStore) prune(now time.Time) {
+	for key, attempt := range s.attempts {
+		if now.Sub(attempt.LastFailureAt) > s.windows[key] && (attempt.LockedUntil == nil || now.After(*attempt.LockedUntil)) {
+			delete(s.attempts, key)
+			delete(s.windows, key)
+		}
+	}
+}
+
+type postgresLoginAttemptStore struct {
+	db *gorm.DB
+}
+
+func NewPostgresLoginAttemptStore(db *gorm.DB) LoginAttemptStore {
+	return &postgresLoginAttemptStore{db: db}
+}
+
+func (s *postgresLoginAttemptStore) Get(key string) (*models.LoginAttempt, error) {
+	var attempts []models.LoginAttempt
+	if err := s.db.Where("key = ?", key).Limit(1).Find(&attempts).Error; err != nil {
+		return nil, err
+	}
+	if len(attempts) == 0 {
+		return nil, nil
+	}
+	return &attempts[0], nil
+}
+
+func (s *postgresLoginAttemptStore) RecordFailure(key string, window time.Duration) (*models.LoginAttempt, error) {
+	now := time.Now()
+	var attempt models.LoginAttempt
+	err := s.db.Raw(`
+		INSERT INTO login_attempts (key, failures, first_failure_at, last_failure_at)
+		VALUES (?, 1, ?, ?)
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE WHEN login_attempts.first_failure_at < ? THEN 1 ELSE login_attempts.failures + 1 END,
+			first_failure_at = CASE WHEN login_attempts.first_failure_at < ? THEN EXCLUDED.first_failure_at ELSE login_attempts.first_failure_at END,
+			last_failure_at = EXCLUDED.last_failure_at
+		RETURNING *`,
+		key, now, now, now.Add(-window), now.Add(-window),
+	).Scan(&attempt).Error
+	if err != nil {
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+func (s *postgresLoginAttemptStore) Lock(key string, until time.Time) error {
+	return s.db.Model(&models.LoginAttempt{}).
+		Where("key = ?", key).
+		Update("locked_until", until).Error
+}
+
+func (s *postgresLoginAttemptStore) Reset(key string) error {
+	return s.db.Delete(&models.LoginAttempt{}, "key = ?", key).Error
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"time"
+
+	"mamonedz/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PersonalAccessTokenRepository interface {
+	Create(token *models.PersonalAccessToken) error
+	GetByHash(hash string) (*models.PersonalAccessToken, error)
+	GetAllByUser(userID uuid.UUID) ([]models.PersonalAccessToken, error)
+	Revoke(id, userID uuid.UUID) error
+	TouchLastUsed(id uuid.UUID, at time.Time) error
+}
+
+type personalAccessTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewPersonalAccessTokenRepository(db *gorm.DB) PersonalAccessTokenRepository {
+	return &personalAccessTokenRepository{db: db}
+}
+
+func (r *personalAccessTokenRepository) Create(token *models.PersonalAccessToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *personalAccessTokenRepository) GetByHash(hash string) (*models.PersonalAccessToken, error) {
+	var token models.PersonalAccessToken
+	err := r.db.First(&token, "token_hash = ? AND revoked_at IS NULL", hash).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *personalAccessTokenRepository) GetAllByUser(userID uuid.UUID) ([]models.PersonalAccessToken, error) {
+	var tokens []models.PersonalAccessToken
+	err := r.db.Where("user_id = ? AND revoked_at IS NULL", userID).
+		Order("created_at DESC").
+		Find(&tokens).Error
+	return tokens, err
+}
+
+func (r *personalAccessTokenRepository) Revoke(id, userID uuid.UUID) error {
+	result := r.db.Model(&models.PersonalAccessToken{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *personalAccessTokenRepository) TouchLastUsed(id uuid.UUID, at time.Time) error {
+	return r.db.Model(&models.PersonalAccessToken{}).
+		Where("id = ?", id).
+		Update("last_used_at", at).Error
+}
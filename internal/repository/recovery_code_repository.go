@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"time"
+
+	"mamonedz/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type RecoveryCodeRepository interface {
+	ReplaceForUser(userID uuid.UUID, codes []models.RecoveryCode) error
+	Consume(userID uuid.UUID, hash string) (bool, error)
+	DeleteForUser(userID uuid.UUID) error
+}
+
+type recoveryCodeRepository struct {
+	db *gorm.DB
+}
+
+func NewRecoveryCodeRepository(db *gorm.DB) RecoveryCodeRepository {
+	return &recoveryCodeRepository{db: db}
+}
+
+func (r *recoveryCodeRepository) ReplaceForUser(userID uuid.UUID, codes []models.RecoveryCode) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.RecoveryCode{}, "user_id = ?", userID).Error; err != nil {
+			return err
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// Consume marks a matching unused code as used and reports whether one was
+// found.
+func (r *recoveryCodeRepository) Consume(userID uuid.UUID, hash string) (bool, error) {
+	result := r.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *recoveryCodeRepository) DeleteForUser(userID uuid.UUID) error {
+	return r.db.Delete(&models.RecoveryCode{}, "user_id = ?", userID).Error
+}
@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"time"
+
+	"mamonedz/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RecurringExpenseRepository interface {
+	Create(template *models.RecurringExpense) error
+	GetByID(id, userID uuid.UUID) (*models.RecurringExpense, error)
+	GetAllByUser(userID uuid.UUID) ([]models.RecurringExpense, error)
+	Update(template *models.RecurringExpense, previous int) error
+	Delete(id, userID uuid.UUID) error
+	SetPaused(id, userID uuid.UUID, pausedAt *time.Time) error
+	GetDue(today time.Time, limit int) ([]models.RecurringExpense, error)
+	Advance(template *models.RecurringExpense, previous int, expense *models.Expense) (bool, error)
+	Resume(template *models.RecurringExpense, previous int) error
+}
+
+type recurringExpenseRepository struct {
+	db *gorm.DB
+}
+
+func NewRecurringExpenseRepository(db *gorm.DB) RecurringExpenseRepository {
+	return &recurringExpenseRepository{db: db}
+}
+
+func (r *recurringExpenseRepository) Create(template *models.RecurringExpense) error {
+	return r.db.Create(template).Error
+}
+
+func (r *recurringExpenseRepository) GetByID(id, userID uuid.UUID) (*models.RecurringExpense, error) {
+	var template models.RecurringExpense
+	err := r.db.First(&template, "id = ? AND user_id = ?", id, userID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+func (r *recurringExpenseRepository) GetAllByUser(userID uuid.UUID) ([]models.RecurringExpense, error) {
+	var templates []models.RecurringExpense
+	err := r.db.Where("user_id = ?", userID).
+		Order("next_date ASC NULLS LAST, created_at ASC").
+		Find(&templates).Error
+	return templates, err
+}
+
+// Update saves the details a user can edit, and NextDate, which follows from
+// them. Like Advance it returns gorm.ErrRecordNotFound without changing
+// anything when the template is no longer at occurrence previous, since
+// NextDate was computed from it.
+func (r *recurringExpenseRepository) Update(template *models.RecurringExpense, previous int) error {
+	result := r.db.Model(template).
+		Where("user_id = ? AND occurrences = ?", template.UserID, previous).
+		Select("Amount", "CategoryID", "AccountID", "Note", "EndDate", "MaxOccurrences", "NextDate", "UpdatedAt").
+		Updates(template)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Delete removes the template. Expenses it generated stay, unlinked.
+func (r *recurringExpenseRepository) Delete(id, userID uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.Expense{}).
+			Where("recurring_id = ? AND user_id = ?", id, userID).
+			Update("recurring_id", nil).Error
+		if err != nil {
+			return err
+		}
+		result := tx.Delete(&models.RecurringExpense{}, "id = ? AND user_id = ?", id, userID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+func (r *recurringExpenseRepository) SetPaused(id, userID uuid.UUID, pausedAt *time.Time) error {
+	result := r.db.Model(&models.RecurringExpense{}).
+		Where("id = ? AND user_id = ?", id, userID).
+		Updates(map[string]interface{}{
+			"paused_at":  pausedAt,
+			"updated_at": gorm.Expr("CURRENT_TIMESTAMP"),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetDue lists active templates with an occurrence due on or before today,
+// across all users.
+func (r *recurringExpenseRepository) GetDue(today time.Time, limit int) ([]models.RecurringExpense, error) {
+	var templates []models.RecurringExpense
+	err := r.db.Where("next_date <= ? AND paused_at IS NULL", today).
+		Order("next_date ASC").
+		Limit(limit).
+		Find(&templates).Error
+	return templates, err
+}
+
+// Advance records that the template moved on from occurrence previous to
+// its current Occurrences and NextDate, creating expense for the occurrence
+// in the same transaction unless it is nil (a skipped occurrence), and
+// reports whether the expense was created. It returns gorm.ErrRecordNotFound
+// without changing anything when the template is no longer at previous,
+// because another scheduler or request got there first.
+//
+// Generated expenses are unique per template and date, so an occurrence can
+// never be created twice even if a stale template is saved over a newer one;
+// the template still advances, but nothing is created.
+func (r *recurringExpenseRepository) Advance(template *models.RecurringExpense, previous int, expense *models.Expense) (bool, error) {
+	created := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.RecurringExpense{}).
+			Where("id = ? AND occurrences = ?", template.ID, previous).
+			Updates(map[string]interface{}{
+				"occurrences": template.Occurrences,
+				"next_date":   template.NextDate,
+				"updated_at":  gorm.Expr("CURRENT_TIMESTAMP"),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		if expense == nil {
+			return nil
+		}
+		result = tx.Clauses(clause.OnConflict{DoNothing: true}).Omit("Tags.*").Create(expense)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		created = true
+		return convertExpense(tx, expense)
+	})
+	return created && err == nil, err
+}
+
+// Resume unpauses the template, moving it on from occurrence previous like
+// Advance without creating anything.
+func (r *recurringExpenseRepository) Resume(template *models.RecurringExpense, previous int) error {
+	result := r.db.Model(&models.RecurringExpense{}).
+		Where("id = ? AND user_id = ? AND occurrences = ?", template.ID, template.UserID, previous).
+		Updates(map[string]interface{}{
+			"occurrences": template.Occurrences,
+			"next_date":   template.NextDate,
+			"paused_at":   nil,
+			"updated_at":  gorm.Expr("CURRENT_TIMESTAMP"),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
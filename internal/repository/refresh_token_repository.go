@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"time"
+
+	"mamonedz/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type RefreshTokenRepository interface {
+	Create(token *models.RefreshToken) error
+	GetByHash(hash string) (*models.RefreshToken, error)
+	Rotate(oldID uuid.UUID, next *models.RefreshToken) error
+	RevokeFamily(familyID uuid.UUID) error
+	RevokeAllForUser(userID uuid.UUID) error
+}
+
+type refreshTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewRefreshTokenRepository(db *gorm.DB) RefreshTokenRepository {
+	return &refreshTokenRepository{db: db}
+}
+
+func (r *refreshTokenRepository) Create(token *models.RefreshToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *refreshTokenRepository) GetByHash(hash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := r.db.First(&token, "token_hash = ?", hash).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// Rotate marks the old token as rotated and stores its successor in one
+// transaction. It returns gorm.ErrRecordNotFound when the old token has
+// already been rotated or revoked, so concurrent refreshes cannot both win.
+func (r *refreshTokenRepository) Rotate(oldID uuid.UUID, next *models.RefreshToken) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND rotated_at IS NULL AND revoked_at IS NULL", oldID).
+			Update("rotated_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Create(next).Error
+	})
+}
+
+func (r *refreshTokenRepository) RevokeFamily(familyID uuid.UUID) error {
+	return r.db.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *refreshTokenRepository) RevokeAllForUser(userID uuid.UUID) error {
+	return r.db.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"time"
+
+	"mamonedz/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RevokedTokenRepository interface {
+	Create(token *models.RevokedToken) error
+	ListSince(since time.Time) ([]models.RevokedToken, error)
+	DeleteExpired(before time.Time) (int64, error)
+}
+
+type revokedTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewRevokedTokenRepository(db *gorm.DB) RevokedTokenRepository {
+	return &revokedTokenRepository{db: db}
+}
+
+func (r *revokedTokenRepository) Create(token *models.RevokedToken) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(token).Error
+}
+
+// ListSince returns unexpired revocations recorded after the given time.
+func (r *revokedTokenRepository) ListSince(since time.Time) ([]models.RevokedToken, error) {
+	var tokens []models.RevokedToken
+	err := r.db.Where("created_at >= ? AND expires_at > ?", since, time.Now()).Find(&tokens).Error
+	return tokens, err
+}
+
+// DeleteExpired removes revocations of tokens that expired before the given
+// time and would be rejected anyway.
+func (r *revokedTokenRepository) DeleteExpired(before time.Time) (int64, error) {
+	result := r.db.Where("expires_at < ?", before).Delete(&models.RevokedToken{})
+	return result.RowsAffected, result.Error
+}
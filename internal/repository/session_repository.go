@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"time"
+
+	"mamonedz/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type SessionRepository interface {
+	Create(session *models.Session) error
+	GetByID(id uuid.UUID) (*models.Session, error)
+	GetActiveByUser(userID uuid.UUID, since time.Time) ([]models.Session, error)
+	Touch(id uuid.UUID, ip string, at time.Time) (bool, error)
+	Revoke(id, userID uuid.UUID) error
+	RevokeAllForUser(userID uuid.UUID) error
+}
+
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository(db *gorm.DB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+func (r *sessionRepository) Create(session *models.Session) error {
+	return r.db.Create(session).Error
+}
+
+func (r *sessionRepository) GetByID(id uuid.UUID) (*models.Session, error) {
+	var session models.Session
+	err := r.db.First(&session, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// GetActiveByUser lists unrevoked sessions seen after since.
+func (r *sessionRepository) GetActiveByUser(userID uuid.UUID, since time.Time) ([]models.Session, error) {
+	var sessions []models.Session
+	err := r.db.Where("user_id = ? AND revoked_at IS NULL AND last_seen_at >= ?", userID, since).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// Touch updates last-seen data and reports whether the session is still
+// active.
+func (r *sessionRepository) Touch(id uuid.UUID, ip string, at time.Time) (bool, error) {
+	result := r.db.Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"last_seen_at": at,
+			"ip":           ip,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *sessionRepository) Revoke(id, userID uuid.UUID) error {
+	result := r.db.Model(&models.Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *sessionRepository) RevokeAllForUser(userID uuid.UUID) error {
+	return r.db.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"time"
+
+	"mamonedz/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type SigningKeyRepository interface {
+	Create(key *models.SigningKey) error
+	GetCreatedSince(since time.Time) ([]models.SigningKey, error)
+	DeleteCreatedBefore(before time.Time) error
+}
+
+type signingKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewSigningKeyRepository(db *gorm.DB) SigningKeyRepository {
+	return &signingKeyRepository{db: db}
+}
+
+func (r *signingKeyRepository) Create(key *models.SigningKey) error {
+	return r.db.Create(key).Error
+}
+
+// GetCreatedSince returns keys created after since, newest first.
+func (r *signingKeyRepository) GetCreatedSince(since time.Time) ([]models.SigningKey, error) {
+	var keys []models.SigningKey
+	err := r.db.Where("created_at > ?", since).Order("created_at DESC").Find(&keys).Error
+	return keys, err
+}
+
+func (r *signingKeyRepository) DeleteCreatedBefore(before time.Time) error {
+	return r.db.Delete(&models.SigningKey{}, "created_at < ?", before).Error
+}
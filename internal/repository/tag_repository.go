@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"mamonedz/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TagRepository interface {
+	Create(tag *models.Tag) error
+	GetByID(id, userID uuid.UUID) (*models.Tag, error)
+	GetAllByUser(userID uuid.UUID) ([]models.TagWithUsage, error)
+	FindOrCreate(userID uuid.UUID, names []string) ([]models.Tag, error)
+	Rename(id, userID uuid.UUID, name string) error
+	Merge(sourceID, targetID, userID uuid.UUID) error
+	Delete(id, userID uuid.UUID) error
+}
+
+type tagRepository struct {
+	db *gorm.DB
+}
+
+func NewTagRepository(db *gorm.DB) TagRepository {
+	return &tagRepository{db: db}
+}
+
+func (r *tagRepository) Create(tag *models.Tag) error {
+	return r.db.Create(tag).Error
+}
+
+func (r *tagRepository) GetByID(id, userID uuid.UUID) (*models.Tag, error) {
+	var tag models.Tag
+	err := r.db.First(&tag, "id = ? AND user_id = ?", id, userID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+func (r *tagRepository) GetAllByUser(userID uuid.UUID) ([]models.TagWithUsage, error) {
+	var tags []models.TagWithUsage
+	err := r.db.Model(&models.Tag{}).
+		Select("tags.*, COUNT(expense_tags.expense_id) as expense_count").
+		Joins("LEFT JOIN expense_tags ON expense_tags.tag_id = tags.id").
+		Where("tags.user_id = ?", userID).
+		Group("tags.id").
+		Order("tags.name ASC").
+		Scan(&tags).Error
+	return tags, err
+}
+
+// FindOrCreate returns the user's tags with the given names, creating the
+// ones that do not exist yet.
+func (r *tagRepository) FindOrCreate(userID uuid.UUID, names []string) ([]models.Tag, error) {
+	if len(names) == 0 {
+		return []models.Tag{}, nil
+	}
+
+	tags := make([]models.Tag, len(names))
+	for i, name := range names {
+		tags[i] = models.Tag{UserID: userID, Name: name}
+	}
+	err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "name"}},
+		DoNothing: true,
+	}).Create(&tags).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var result []models.Tag
+	err = r.db.Where("user_id = ? AND name IN ?", userID, names).
+		Order("name ASC").
+		Find(&result).Error
+	return result, err
+}
+
+func (r *tagRepository) Rename(id, userID uuid.UUID, name string) error {
+	result := r.db.Model(&models.Tag{}).
+		Where("id = ? AND user_id = ?", id, userID).
+		Update("name", name)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Merge moves every expense of the source tag to the target tag and deletes
+// the source. Expenses that already carry both tags keep a single link.
+func (r *tagRepository) Merge(sourceID, targetID, userID uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`
+			INSERT INTO expense_tags (expense_id, tag_id)
+			SELECT expense_id, ? FROM expense_tags WHERE tag_id = ?
+			ON CONFLICT DO NOTHING`,
+			targetID, sourceID,
+		).Error
+		if err != nil {
+			return err
+		}
+		return deleteTag(tx, sourceID, userID)
+	})
+}
+
+func (r *tagRepository) Delete(id, userID uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return deleteTag(tx, id, userID)
+	})
+}
+
+func deleteTag(tx *gorm.DB, id, userID uuid.UUID) error {
+	err := tx.Exec(
+		"DELETE FROM expense_tags WHERE tag_id IN (SELECT id FROM tags WHERE id = ? AND user_id = ?)",
+		id, userID,
+	).Error
+	if err != nil {
+		return err
+	}
+
+	result := tx.Delete(&models.Tag{}, "id = ? AND user_id = ?", id, userID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"mamonedz/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type TransferRepository interface {
+	Create(transfer *models.Transfer, fee *models.Expense) error
+	GetByID(id, userID uuid.UUID) (*models.Transfer, error)
+	GetAll(filter *models.TransferFilter) ([]models.Transfer, int64, error)
+	Delete(id, userID uuid.UUID) error
+}
+
+type transferRepository struct {
+	db *gorm.DB
+}
+
+func NewTransferRepository(db *gorm.DB) TransferRepository {
+	return &transferRepository{db: db}
+}
+
+// Create inserts the transfer and, when fee is not nil, the expense recording
+// its fee, in a single transaction.
+func (r *transferRepository) Create(transfer *models.Transfer, fee *models.Expense) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if fee != nil {
+			if err := tx.Omit("Tags.*").Create(fee).Error; err != nil {
+				return err
+			}
+			if err := convertExpense(tx, fee); err != nil {
+				return err
+			}
+			transfer.FeeExpenseID = &fee.ID
+		}
+		return tx.Create(transfer).Error
+	})
+}
+
+func (r *transferRepository) GetByID(id, userID uuid.UUID) (*models.Transfer, error) {
+	var transfer models.Transfer
+	err := r.db.First(&transfer, "id = ? AND user_id = ?", id, userID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+func (r *transferRepository) GetAll(filter *models.TransferFilter) ([]models.Transfer, int64, error) {
+	var transfers []models.Transfer
+	var total int64
+
+	query := r.db.Model(&models.Transfer{}).Where("user_id = ?", filter.UserID)
+	if filter.AccountID != nil {
+		query = query.Where("from_account_id = ? OR to_account_id = ?", *filter.AccountID, *filter.AccountID)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+
+	err := query.Order("date DESC, created_at DESC").Find(&transfers).Error
+	return transfers, total, err
+}
+
+// Delete removes the transfer together with its fee expense. Goal
+// contributions recorded from it stay but lose the link.
+func (r *transferRepository) Delete(id, userID uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var transfer models.Transfer
+		if err := tx.First(&transfer, "id = ? AND user_id = ?", id, userID).Error; err != nil {
+			return err
+		}
+		err := tx.Model(&models.GoalContribution{}).
+			Where("transfer_id = ? AND user_id = ?", id, userID).
+			Update("transfer_id", nil).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Delete(&transfer).Error; err != nil {
+			return err
+		}
+		if transfer.FeeExpenseID == nil {
+			return nil
+		}
+		if err := tx.Exec("DELETE FROM expense_tags WHERE expense_id = ?", *transfer.FeeExpenseID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Expense{}, "id = ? AND user_id = ?", *transfer.FeeExpenseID, userID).Error
+	})
+}
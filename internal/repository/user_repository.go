@@ -1,6 +1,9 @@
 package repository
 
 import (
+	"strings"
+	"time"
+
 	"mamonedz/internal/models"
 
 	"github.com/google/uuid"
@@ -11,7 +14,47 @@ type UserRepository interface {
 	Create(user *models.User) error
 	GetByID(id uuid.UUID) (*models.User, error)
 	GetByEmail(email string) (*models.User, error)
+	GetByEmailFold(email string) (*models.User, error)
 	ExistsByEmail(email string) (bool, error)
+	IncrementTokenVersion(id uuid.UUID) error
+	UpdatePassword(id uuid.UUID, hashedPassword string) error
+	MarkEmailVerified(id uuid.UUID) error
+	SetTOTPSecret(id uuid.UUID, secret string) error
+	EnableTOTP(id uuid.UUID, step int64) error
+	DisableTOTP(id uuid.UUID) error
+	AdvanceTOTPStep(id uuid.UUID, step int64) (bool, error)
+	UpdateEmail(id uuid.UUID, email string) error
+	OwnsSharedLedger(id uuid.UUID) (bool, error)
+	Delete(id uuid.UUID) error
+	Search(filter *models.UserFilter) ([]models.User, int64, error)
+	SetRole(id uuid.UUID, role string) error
+	SetDisabled(id uuid.UUID, disabledAt *time.Time) error
+	PromoteByEmails(emails []string) (int64, error)
+	SetBaseCurrency(id uuid.UUID, currency string) error
+}
+
+// ownedModels lists every table that references users.user_id, except
+// expenses, which Delete handles separately. Delete removes rows from all of
+// them before the user itself, after emptying the ledgers the user owns.
+var ownedModels = []interface{}{
+	&models.RefreshToken{},
+	&models.RevokedToken{},
+	&models.UserToken{},
+	&models.RecoveryCode{},
+	&models.PersonalAccessToken{},
+	&models.Session{},
+	&models.ExternalIdentity{},
+	&models.Category{},
+	&models.Tag{},
+	&models.Account{},
+	&models.Transfer{},
+	&models.RecurringExpense{},
+	&models.BudgetAlert{},
+	&models.Budget{},
+	&models.GoalContribution{},
+	&models.SavingsGoal{},
+	&models.LedgerMember{},
+	&models.Ledger{},
 }
 
 type userRepository struct {
@@ -22,8 +65,23 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 	return &userRepository{db: db}
 }
 
+// Create inserts the user together with their default categories and
+// personal ledger.
 func (r *userRepository) Create(user *models.User) error {
-	return r.db.Create(user).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(models.NewDefaultCategories(user.ID)).Error; err != nil {
+			return err
+		}
+		return createLedger(tx, &models.Ledger{
+			UserID:       user.ID,
+			Name:         models.PersonalLedgerName,
+			BaseCurrency: user.BaseCurrency,
+			Personal:     true,
+		})
+	})
 }
 
 func (r *userRepository) GetByID(id uuid.UUID) (*models.User, error) {
@@ -44,8 +102,273 @@ func (r *userRepository) GetByEmail(email string) (*models.User, error) {
 	return &user, nil
 }
 
+// GetByEmailFold looks the user up by email, ignoring case.
+func (r *userRepository) GetByEmailFold(email string) (*models.User, error) {
+	var user models.User
+	err := r.db.First(&user, "LOWER(email) = LOWER(?)", email).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
 func (r *userRepository) ExistsByEmail(email string) (bool, error) {
 	var count int64
 	err := r.db.Model(&models.User{}).Where("email = ?", email).Count(&count).Error
 	return count > 0, err
 }
+
+func (r *userRepository) IncrementTokenVersion(id uuid.UUID) error {
+	return r.db.Model(&models.User{}).
+		Where("id = ?", id).
+		Update("token_version", gorm.Expr("token_version + 1")).Error
+}
+
+func (r *userRepository) UpdatePassword(id uuid.UUID, hashedPassword string) error {
+	return r.db.Model(&models.User{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"password":   hashedPassword,
+			"updated_at": gorm.Expr("CURRENT_TIMESTAMP"),
+		}).Error
+}
+
+func (r *userRepository) MarkEmailVerified(id uuid.UUID) error {
+	return r.db.Model(&models.User{}).
+		Where("id = ? AND email_verified_at IS NULL", id).
+		Update("email_verified_at", time.Now()).Error
+}
+
+// SetTOTPSecret stores a pending secret; it only takes effect once EnableTOTP
+// has been called.
+func (r *userRepository) SetTOTPSecret(id uuid.UUID, secret string) error {
+	return r.db.Model(&models.User{}).
+		Where("id = ? AND totp_enabled_at IS NULL", id).
+		Update("totp_secret", secret).Error
+}
+
+func (r *userRepository) EnableTOTP(id uuid.UUID, step int64) error {
+	return r.db.Model(&models.User{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"totp_enabled_at": time.Now(),
+			"totp_last_step":  step,
+		}).Error
+}
+
+func (r *userRepository) DisableTOTP(id uuid.UUID) error {
+	return r.db.Model(&models.User{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"totp_secret":     nil,
+			"totp_enabled_at": nil,
+			"totp_last_step":  0,
+		}).Error
+}
+
+// AdvanceTOTPStep records step as the last accepted TOTP step. It reports
+// false when the step is not newer than the stored one, which means the code
+// was already used.
+func (r *userRepository) AdvanceTOTPStep(id uuid.UUID, step int64) (bool, error) {
+	result := r.db.Model(&models.User{}).
+		Where("id = ? AND totp_last_step < ?", id, step).
+		Update("totp_last_step", step)
+	return result.RowsAffected > 0, result.Error
+}
+
+// UpdateEmail changes the address and marks it verified, since it is only
+// called after the new address has been confirmed.
+func (r *userRepository) UpdateEmail(id uuid.UUID, email string) error {
+	return r.db.Model(&models.User{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"email":             email,
+			"email_verified_at": time.Now(),
+			"updated_at":        gorm.Expr("CURRENT_TIMESTAMP"),
+		}).Error
+}
+
+// OwnsSharedLedger reports whether the user owns a ledger that has other
+// members. Such a user cannot be deleted, since that would delete the
+// ledger and everything the other members recorded in it.
+func (r *userRepository) OwnsSharedLedger(id uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.LedgerMember{}).
+		Joins("JOIN ledgers ON ledgers.id = ledger_members.ledger_id").
+		Where("ledgers.user_id = ? AND ledger_members.user_id <> ?", id, id).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// Delete removes the user together with everything they own in a single
+// transaction. Ledgers they own go with all their transactions, members and
+// invitations. What they recorded or paid for in other ledgers stays there
+// and is handed over to the owner of that ledger: the category is matched
+// by name in the owner's categories, created if missing, and the account,
+// tags and recurring template, which were the user's, are dropped.
+func (r *userRepository) Delete(id uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		owned := tx.Model(&models.Ledger{}).Select("id").Where("user_id = ?", id)
+		err := tx.Exec(
+			"DELETE FROM expense_tags WHERE expense_id IN (SELECT id FROM expenses WHERE ledger_id IN (?)) OR tag_id IN (SELECT id FROM tags WHERE user_id = ?)",
+			owned, id,
+		).Error
+		if err != nil {
+			return err
+		}
+		for _, model := range []interface{}{&models.Expense{}, &models.LedgerInvitation{}, &models.LedgerMember{}} {
+			if err := tx.Where("ledger_id IN (?)", owned).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+
+		err = tx.Exec(`
+			INSERT INTO categories (user_id, type, name, sort_order)
+			SELECT DISTINCT ledgers.user_id, expenses.type, expenses.category, ?
+			FROM expenses
+			JOIN ledgers ON ledgers.id = expenses.ledger_id
+			WHERE expenses.user_id = ?
+			ON CONFLICT (user_id, type, name) DO NOTHING`,
+			len(models.DefaultCategories), id,
+		).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Exec(`
+			UPDATE expenses SET
+				user_id = ledgers.user_id,
+				category_id = categories.id,
+				account_id = NULL,
+				recurring_id = NULL,
+				updated_at = CURRENT_TIMESTAMP
+			FROM ledgers, categories
+			WHERE expenses.user_id = ?
+				AND ledgers.id = expenses.ledger_id
+				AND categories.user_id = ledgers.user_id
+				AND categories.type = expenses.type
+				AND categories.name = expenses.category`,
+			id,
+		).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Exec(`
+			UPDATE expenses SET paid_by = ledgers.user_id
+			FROM ledgers
+			WHERE expenses.paid_by = ? AND ledgers.id = expenses.ledger_id`,
+			id,
+		).Error
+		if err != nil {
+			return err
+		}
+
+		for _, model := range ownedModels {
+			if err := tx.Where("user_id = ?", id).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		result := tx.Delete(&models.User{}, "id = ?", id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// Search lists users matching filter. Search matches name or email,
+// case-insensitively.
+func (r *userRepository) Search(filter *models.UserFilter) ([]models.User, int64, error) {
+	var users []models.User
+	var total int64
+
+	query := r.db.Model(&models.User{})
+	if filter.Search != "" {
+		pattern := "%" + strings.ToLower(filter.Search) + "%"
+		query = query.Where("LOWER(name) LIKE ? OR LOWER(email) LIKE ?", pattern, pattern)
+	}
+	if filter.Role != "" {
+		query = query.Where("role = ?", filter.Role)
+	}
+	if filter.Disabled != nil {
+		if *filter.Disabled {
+			query = query.Where("disabled_at IS NOT NULL")
+		} else {
+			query = query.Where("disabled_at IS NULL")
+		}
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+
+	err := query.Order("created_at DESC").Find(&users).Error
+	return users, total, err
+}
+
+func (r *userRepository) SetRole(id uuid.UUID, role string) error {
+	result := r.db.Model(&models.User{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"role":       role,
+			"updated_at": gorm.Expr("CURRENT_TIMESTAMP"),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// SetDisabled disables the user at disabledAt, or re-enables them when it is
+// nil.
+func (r *userRepository) SetDisabled(id uuid.UUID, disabledAt *time.Time) error {
+	result := r.db.Model(&models.User{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"disabled_at": disabledAt,
+			"updated_at":  gorm.Expr("CURRENT_TIMESTAMP"),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// PromoteByEmails makes the users with the given emails admins and returns
+// how many were changed.
+func (r *userRepository) PromoteByEmails(emails []string) (int64, error) {
+	result := r.db.Model(&models.User{}).
+		Where("email IN ? AND role <> ?", emails, models.RoleAdmin).
+		Update("role", models.RoleAdmin)
+	return result.RowsAffected, result.Error
+}
+
+// SetBaseCurrency changes the user's base currency and that of their
+// personal ledger.
+func (r *userRepository) SetBaseCurrency(id uuid.UUID, currency string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{
+			"base_currency": currency,
+			"updated_at":    gorm.Expr("CURRENT_TIMESTAMP"),
+		}
+		if err := tx.Model(&models.User{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Ledger{}).Where("user_id = ? AND personal", id).Updates(updates).Error
+	})
+}
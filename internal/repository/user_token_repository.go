@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"time"
+
+	"mamonedz/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type UserTokenRepository interface {
+	Create(token *models.UserToken) error
+	Consume(hash string, purposes ...string) (*models.UserToken, error)
+	InvalidateForUser(userID uuid.UUID, purpose string) error
+}
+
+type userTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewUserTokenRepository(db *gorm.DB) UserTokenRepository {
+	return &userTokenRepository{db: db}
+}
+
+func (r *userTokenRepository) Create(token *models.UserToken) error {
+	return r.db.Create(token).Error
+}
+
+// Consume marks an unused, unexpired token as used and returns it. The check
+// and the update happen in a single statement, so a token can only be
+// consumed once even under concurrent requests.
+func (r *userTokenRepository) Consume(hash string, purposes ...string) (*models.UserToken, error) {
+	var tokens []models.UserToken
+	now := time.Now()
+	result := r.db.Model(&tokens).
+		Clauses(clause.Returning{}).
+		Where("token_hash = ? AND purpose IN ? AND used_at IS NULL AND expires_at > ?", hash, purposes, now).
+		Update("used_at", now)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if len(tokens) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &tokens[0], nil
+}
+
+func (r *userTokenRepository) InvalidateForUser(userID uuid.UUID, purpose string) error {
+	return r.db.Model(&models.UserToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Update("used_at", time.Now()).Error
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"mamonedz/internal/mailer"
+	"mamonedz/internal/models"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrSameEmail         = errors.New("new email is the same as the current one")
+	ErrOwnsSharedLedgers = errors.New("remove the other members from your ledgers first")
+	ErrReauthRequired    = errors.New("sign in again to confirm this change")
+)
+
+// ChangePassword replaces the password after checking the current one. All
+// existing tokens are revoked and a fresh pair is returned for the caller, so
+// only the device that made the change stays signed in. An account without
+// a password uses this to set one.
+func (s *authService) ChangePassword(claims *TokenClaims, req *models.ChangePasswordRequest, client *models.ClientInfo) (*models.AuthResponse, error) {
+	user, err := s.GetUserByID(claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.confirmIdentity(user, req.CurrentPassword, claims.SessionID); err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.UpdatePassword(user.ID, string(hashedPassword)); err != nil {
+		return nil, err
+	}
+	if err := s.LogoutAll(user.ID); err != nil {
+		return nil, err
+	}
+
+	user, err = s.GetUserByID(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	return s.startSession(user, client)
+}
+
+// ChangeEmail sends a confirmation link to the new address. The address on
+// the account only changes once that link is opened.
+func (s *authService) ChangeEmail(claims *TokenClaims, req *models.ChangeEmailRequest) error {
+	user, err := s.GetUserByID(claims.UserID)
+	if err != nil {
+		return err
+	}
+	if err := s.confirmIdentity(user, req.Password, claims.SessionID); err != nil {
+		return err
+	}
+	if req.Email == user.Email {
+		return ErrSameEmail
+	}
+
+	exists, err := s.userRepo.ExistsByEmail(req.Email)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrEmailAlreadyExists
+	}
+
+	if err := s.userTokenRepo.InvalidateForUser(user.ID, models.TokenPurposeEmailChange); err != nil {
+		return err
+	}
+	raw, err := s.createUserToken(user.ID, models.TokenPurposeEmailChange, req.Email, s.verificationTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(&mailer.Message{
+		To:      req.Email,
+		Subject: "Confirm your new Mamonedz email",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nOpen the link below to use this address for your Mamonedz account. It expires in %s.\n\n%s/verify-email?token=%s\n",
+			user.Name, s.verificationTTL, s.appURL, raw,
+		),
+	})
+}
+
+func (s *authService) confirmEmailChange(token *models.UserToken) error {
+	user, err := s.GetUserByID(token.UserID)
+	if err != nil {
+		return err
+	}
+
+	// The address may have been taken by someone else since the link was sent.
+	exists, err := s.userRepo.ExistsByEmail(token.Email)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrEmailAlreadyExists
+	}
+
+	if err := s.userRepo.UpdateEmail(user.ID, token.Email); err != nil {
+		return err
+	}
+
+	if err := s.mailer.Send(&mailer.Message{
+		To:      user.Email,
+		Subject: "Your Mamonedz email was changed",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nThe email address of your Mamonedz account was changed to %s. If this was not you, reset your password immediately.\n",
+			user.Name, token.Email,
+		),
+	}); err != nil {
+		log.Printf("Failed to notify %s about email change: %v", user.Email, err)
+	}
+	return nil
+}
+
+// DeleteAccount permanently removes the user and all data they own. It is
+// refused while the user owns a ledger with other members, whose records
+// would otherwise go with it.
+func (s *authService) DeleteAccount(claims *TokenClaims, req *models.DeleteAccountRequest) error {
+	user, err := s.GetUserByID(claims.UserID)
+	if err != nil {
+		return err
+	}
+	if err := s.confirmIdentity(user, req.Password, claims.SessionID); err != nil {
+		return err
+	}
+
+	shared, err := s.userRepo.OwnsSharedLedger(user.ID)
+	if err != nil {
+		return err
+	}
+	if shared {
+		return ErrOwnsSharedLedgers
+	}
+
+	if err := s.userRepo.Delete(user.ID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	return s.loginGuard.Succeed(user.Email)
+}
+
+// confirmIdentity checks the password before a sensitive account change.
+// Accounts without one (social or magic-link sign-in, or after an admin
+// forced a password reset) must instead be using a session that was signed in
+// within the last REAUTH_WINDOW.
+func (s *authService) confirmIdentity(user *models.User, password string, sessionID uuid.UUID) error {
+	if user.Password != "" {
+		if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+			return ErrInvalidCredentials
+		}
+		return nil
+	}
+
+	if sessionID == uuid.Nil {
+		return ErrReauthRequired
+	}
+	session, err := s.sessionRepo.GetByID(sessionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrReauthRequired
+		}
+		return err
+	}
+	if session.UserID != user.ID || session.RevokedAt != nil || time.Since(session.CreatedAt) > s.reauthWindow {
+		return ErrReauthRequired
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"mamonedz/internal/models"
+	"mamonedz/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAccountNotFound       = errors.New("account not found")
+	ErrAccountInUse          = errors.New("account has transactions")
+	ErrAccountCurrencyLocked = errors.New("account currency cannot change once it has transactions")
+)
+
+type AccountService interface {
+	Create(userID uuid.UUID, req *models.CreateAccountRequest) (*models.AccountWithBalance, error)
+	GetAll(userID uuid.UUID, asOf *time.Time) ([]models.AccountWithBalance, error)
+	GetByID(id, userID uuid.UUID, asOf *time.Time) (*models.AccountWithBalance, error)
+	Update(id, userID uuid.UUID, req *models.UpdateAccountRequest) (*models.AccountWithBalance, error)
+	Delete(id, userID uuid.UUID) error
+	GetHistory(id, userID uuid.UUID, limit, offset int) ([]models.AccountHistoryEntry, int64, error)
+}
+
+type accountService struct {
+	repo repository.AccountRepository
+}
+
+func NewAccountService(repo repository.AccountRepository) AccountService {
+	return &accountService{repo: repo}
+}
+
+func (s *accountService) Create(userID uuid.UUID, req *models.CreateAccountRequest) (*models.AccountWithBalance, error) {
+	currency := strings.ToUpper(req.Currency)
+	if currency == "" {
+		currency = "IDR"
+	}
+
+	account := &models.Account{
+		UserID:         userID,
+		Name:           strings.TrimSpace(req.Name),
+		Type:           req.Type,
+		OpeningBalance: req.OpeningBalance,
+		Currency:       currency,
+	}
+	if err := s.repo.Create(account); err != nil {
+		return nil, err
+	}
+	return &models.AccountWithBalance{Account: *account, Balance: account.OpeningBalance}, nil
+}
+
+// GetAll lists the user's accounts with their balances as of the end of the
+// given day, or current balances when asOf is nil.
+func (s *accountService) GetAll(userID uuid.UUID, asOf *time.Time) ([]models.AccountWithBalance, error) {
+	accounts, err := s.repo.GetAllByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	movements, err := s.repo.GetMovements(userID, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]models.AccountWithBalance, len(accounts))
+	for i, account := range accounts {
+		result[i] = models.AccountWithBalance{
+			Account: account,
+			Balance: account.OpeningBalance + movements[account.ID],
+		}
+	}
+	return result, nil
+}
+
+func (s *accountService) GetByID(id, userID uuid.UUID, asOf *time.Time) (*models.AccountWithBalance, error) {
+	account, err := s.get(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.withBalance(account, asOf)
+}
+
+// Update changes the account. Its currency can only change while it has no
+// transactions or transfers, which were recorded in the old currency.
+func (s *accountService) Update(id, userID uuid.UUID, req *models.UpdateAccountRequest) (*models.AccountWithBalance, error) {
+	account, err := s.get(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		account.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Type != nil {
+		account.Type = *req.Type
+	}
+	if req.OpeningBalance != nil {
+		account.OpeningBalance = *req.OpeningBalance
+	}
+	if req.Currency != nil {
+		currency := strings.ToUpper(*req.Currency)
+		if currency != account.Currency {
+			count, err := s.repo.CountTransactions(id, userID)
+			if err != nil {
+				return nil, err
+			}
+			if count > 0 {
+				return nil, ErrAccountCurrencyLocked
+			}
+			account.Currency = currency
+		}
+	}
+
+	account.UpdatedAt = time.Now()
+
+	if err := s.repo.Update(account); err != nil {
+		return nil, err
+	}
+	return s.withBalance(account, nil)
+}
+
+// Delete removes an account that has no transactions or transfers. Accounts in use have
+// to keep existing so that history stays intact.
+func (s *accountService) Delete(id, userID uuid.UUID) error {
+	if _, err := s.get(id, userID); err != nil {
+		return err
+	}
+
+	count, err := s.repo.CountTransactions(id, userID)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrAccountInUse
+	}
+
+	if err := s.repo.Delete(id, userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrAccountNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *accountService) GetHistory(id, userID uuid.UUID, limit, offset int) ([]models.AccountHistoryEntry, int64, error) {
+	if _, err := s.get(id, userID); err != nil {
+		return nil, 0, err
+	}
+	return s.repo.GetHistory(id, userID, limit, offset)
+}
+
+func (s *accountService) get(id, userID uuid.UUID) (*models.Account, error) {
+	account, err := s.repo.GetByID(id, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAccountNotFound
+		}
+		return nil, err
+	}
+	return account, nil
+}
+
+func (s *accountService) withBalance(account *models.Account, asOf *time.Time) (*models.AccountWithBalance, error) {
+	movements, err := s.repo.GetMovements(account.UserID, asOf)
+	if err != nil {
+		return nil, err
+	}
+	return &models.AccountWithBalance{
+		Account: *account,
+		Balance: account.OpeningBalance + movements[account.ID],
+	}, nil
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"mamonedz/internal/models"
+	"mamonedz/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCannotModifySelf = errors.New("administrators cannot change their own account this way")
+	ErrInvalidRole      = errors.New("invalid role")
+)
+
+// platformStatsWindow is the period "new" and "active" users are counted over.
+const platformStatsWindow = 30 * 24 * time.Hour
+
+type AdminService interface {
+	ListUsers(filter *models.UserFilter) ([]models.AdminUserResponse, int64, error)
+	GetUser(id uuid.UUID) (*models.AdminUserResponse, error)
+	DisableUser(id, adminID uuid.UUID) error
+	EnableUser(id uuid.UUID) error
+	ForcePasswordReset(id uuid.UUID) error
+	SetRole(id, adminID uuid.UUID, req *models.UpdateRoleRequest) error
+	GetStats() (*models.PlatformStats, error)
+	PromoteAdmins(emails []string) (int64, error)
+}
+
+type adminService struct {
+	userRepo    repository.UserRepository
+	adminRepo   repository.AdminRepository
+	authService AuthService
+}
+
+func NewAdminService(userRepo repository.UserRepository, adminRepo repository.AdminRepository, authService AuthService) AdminService {
+	return &adminService{
+		userRepo:    userRepo,
+		adminRepo:   adminRepo,
+		authService: authService,
+	}
+}
+
+func (s *adminService) ListUsers(filter *models.UserFilter) ([]models.AdminUserResponse, int64, error) {
+	if filter.Role != "" && !models.ValidRoles[filter.Role] {
+		return nil, 0, ErrInvalidRole
+	}
+
+	users, total, err := s.userRepo.Search(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	result := make([]models.AdminUserResponse, len(users))
+	for i := range users {
+		result[i] = *users[i].ToAdminResponse()
+	}
+	return result, total, nil
+}
+
+func (s *adminService) GetUser(id uuid.UUID) (*models.AdminUserResponse, error) {
+	user, err := s.authService.GetUserByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return user.ToAdminResponse(), nil
+}
+
+// DisableUser blocks the account and signs it out everywhere. Admins cannot
+// disable themselves, so there is always a way back in.
+func (s *adminService) DisableUser(id, adminID uuid.UUID) error {
+	if id == adminID {
+		return ErrCannotModifySelf
+	}
+
+	now := time.Now()
+	if err := s.userRepo.SetDisabled(id, &now); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	return s.authService.LogoutAll(id)
+}
+
+func (s *adminService) EnableUser(id uuid.UUID) error {
+	if err := s.userRepo.SetDisabled(id, nil); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// ForcePasswordReset invalidates the current password, signs the user out
+// everywhere and emails them a reset link.
+func (s *adminService) ForcePasswordReset(id uuid.UUID) error {
+	user, err := s.authService.GetUserByID(id)
+	if err != nil {
+		return err
+	}
+
+	// An empty hash never matches, so the old password stops working.
+	if err := s.userRepo.UpdatePassword(user.ID, ""); err != nil {
+		return err
+	}
+	if err := s.authService.LogoutAll(user.ID); err != nil {
+		return err
+	}
+	return s.authService.ForgotPassword(&models.ForgotPasswordRequest{Email: user.Email})
+}
+
+func (s *adminService) SetRole(id, adminID uuid.UUID, req *models.UpdateRoleRequest) error {
+	if !models.ValidRoles[req.Role] {
+		return ErrInvalidRole
+	}
+	if id == adminID {
+		return ErrCannotModifySelf
+	}
+
+	if err := s.userRepo.SetRole(id, req.Role); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *adminService) GetStats() (*models.PlatformStats, error) {
+	return s.adminRepo.GetPlatformStats(time.Now().Add(-platformStatsWindow))
+}
+
+// PromoteAdmins grants the admin role to existing users with the given
+// emails. It is used at startup to bootstrap the first administrators.
+func (s *adminService) PromoteAdmins(emails []string) (int64, error) {
+	var cleaned []string
+	for _, email := range emails {
+		if email = strings.TrimSpace(email); email != "" {
+			cleaned = append(cleaned, email)
+		}
+	}
+	if len(cleaned) == 0 {
+		return 0, nil
+	}
+	return s.userRepo.PromoteByEmails(cleaned)
+}
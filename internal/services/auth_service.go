@@ -2,9 +2,13 @@ package services
 
 import (
 	"errors"
+	"fmt"
+	"log"
+	"strings"
 	"time"
 
 	"mamonedz/internal/config"
+	"mamonedz/internal/mailer"
 	"mamonedz/internal/models"
 	"mamonedz/internal/repository"
 
@@ -19,28 +23,144 @@ var (
 	ErrEmailAlreadyExists = errors.New("email already exists")
 	ErrInvalidCredentials = errors.New("invalid credentials")
 	ErrInvalidToken       = errors.New("invalid token")
+	ErrInvalidRefresh     = errors.New("invalid or expired refresh token")
+	ErrRefreshReused      = errors.New("refresh token reuse detected")
+	ErrInvalidResetToken  = errors.New("invalid or expired reset token")
+	ErrInvalidVerifyToken = errors.New("invalid or expired verification token")
+	ErrAlreadyVerified    = errors.New("email already verified")
+	ErrInvalidMagicLink   = errors.New("invalid or expired login link")
+	ErrAccountDisabled    = errors.New("account disabled")
+)
+
+const (
+	tokenTypeAccess = "access"
+	tokenTypeMFA    = "mfa_pending"
 )
 
 type AuthService interface {
-	Register(req *models.RegisterRequest) (*models.AuthResponse, error)
-	Login(req *models.LoginRequest) (*models.AuthResponse, error)
+	Register(req *models.RegisterRequest, client *models.ClientInfo) (*models.AuthResponse, error)
+	Login(req *models.LoginRequest, client *models.ClientInfo) (*models.AuthResponse, error)
+	Refresh(req *models.RefreshTokenRequest, client *models.ClientInfo) (*models.AuthResponse, error)
+	CompleteLogin(user *models.User, client *models.ClientInfo) (*models.AuthResponse, error)
+	SendMagicLink(req *models.MagicLinkRequest) error
+	LoginWithMagicLink(req *models.MagicLinkLoginRequest, client *models.ClientInfo) (*models.AuthResponse, error)
+	Logout(claims *TokenClaims, req *models.LogoutRequest) error
+	LogoutAll(userID uuid.UUID) error
+	ForgotPassword(req *models.ForgotPasswordRequest) error
+	ResetPassword(req *models.ResetPasswordRequest) error
+	VerifyEmail(token string) error
+	ResendVerification(userID uuid.UUID) error
+	ChangePassword(claims *TokenClaims, req *models.ChangePasswordRequest, client *models.ClientInfo) (*models.AuthResponse, error)
+	ChangeEmail(claims *TokenClaims, req *models.ChangeEmailRequest) error
+	DeleteAccount(claims *TokenClaims, req *models.DeleteAccountRequest) error
+	SetupMFA(userID uuid.UUID) (*models.MFASetupResponse, error)
+	EnableMFA(userID uuid.UUID, req *models.MFAEnableRequest) (*models.MFAEnableResponse, error)
+	DisableMFA(userID uuid.UUID, req *models.MFADisableRequest) error
+	VerifyMFA(req *models.MFAVerifyRequest, client *models.ClientInfo) (*models.AuthResponse, error)
 	GetUserByID(id uuid.UUID) (*models.User, error)
-	ValidateToken(tokenString string) (*uuid.UUID, error)
+	ValidateToken(tokenString string) (*TokenClaims, error)
+	Authenticate(tokenString string) (*models.User, *TokenClaims, error)
+	TouchSession(claims *TokenClaims, client *models.ClientInfo) error
+	GetSessions(userID uuid.UUID, current uuid.UUID) ([]models.SessionResponse, error)
+	RevokeSession(id, userID uuid.UUID) error
+	JWKS() (*models.JWKS, error)
+}
+
+// TokenClaims holds the validated claims of an access token.
+type TokenClaims struct {
+	UserID       uuid.UUID
+	TokenID      string
+	SessionID    uuid.UUID
+	TokenVersion int
+	ExpiresAt    time.Time
+	// Scopes is only set for personal access tokens. Session tokens are
+	// unrestricted.
+	Scopes []string
+}
+
+func (c *TokenClaims) IsPersonalAccessToken() bool {
+	return c.Scopes != nil
+}
+
+func (c *TokenClaims) HasScope(scope string) bool {
+	if !c.IsPersonalAccessToken() {
+		return true
+	}
+	for _, s := range c.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
 }
 
 type authService struct {
-	userRepo  repository.UserRepository
-	jwtSecret string
+	userRepo         repository.UserRepository
+	refreshTokenRepo repository.RefreshTokenRepository
+	revokedTokenRepo repository.RevokedTokenRepository
+	userTokenRepo    repository.UserTokenRepository
+	recoveryCodeRepo repository.RecoveryCodeRepository
+	sessionRepo      repository.SessionRepository
+	patService       PersonalAccessTokenService
+	mailer           mailer.Mailer
+	denylist         *tokenDenylist
+	loginGuard       *loginGuard
+	magicLinkLimiter *magicLinkLimiter
+	sessionTouches   *sessionTouches
+	keyring          *keyring
+	issuer           string
+	appURL           string
+	accessTokenTTL   time.Duration
+	refreshTokenTTL  time.Duration
+	passwordResetTTL time.Duration
+	verificationTTL  time.Duration
+	magicLinkTTL     time.Duration
+	totpIssuer       string
+	mfaChallengeTTL  time.Duration
+	reauthWindow     time.Duration
 }
 
-func NewAuthService(userRepo repository.UserRepository, cfg *config.Config) AuthService {
+func NewAuthService(
+	userRepo repository.UserRepository,
+	refreshTokenRepo repository.RefreshTokenRepository,
+	revokedTokenRepo repository.RevokedTokenRepository,
+	userTokenRepo repository.UserTokenRepository,
+	recoveryCodeRepo repository.RecoveryCodeRepository,
+	sessionRepo repository.SessionRepository,
+	signingKeyRepo repository.SigningKeyRepository,
+	loginAttempts repository.LoginAttemptStore,
+	patService PersonalAccessTokenService,
+	mailer mailer.Mailer,
+	cfg *config.Config,
+) AuthService {
 	return &authService{
-		userRepo:  userRepo,
-		jwtSecret: cfg.JWTSecret,
+		userRepo:         userRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		revokedTokenRepo: revokedTokenRepo,
+		userTokenRepo:    userTokenRepo,
+		recoveryCodeRepo: recoveryCodeRepo,
+		sessionRepo:      sessionRepo,
+		patService:       patService,
+		mailer:           mailer,
+		denylist:         newTokenDenylist(revokedTokenRepo, cfg.RevocationSyncInterval),
+		loginGuard:       newLoginGuard(loginAttempts, cfg),
+		magicLinkLimiter: newMagicLinkLimiter(loginAttempts, cfg),
+		sessionTouches:   newSessionTouches(cfg.SessionTouchInterval),
+		keyring:          newKeyring(signingKeyRepo, cfg.JWTAlgorithm, cfg.JWTKeyRotation, cfg.AccessTokenTTL),
+		issuer:           cfg.JWTIssuer,
+		appURL:           strings.TrimRight(cfg.AppURL, "/"),
+		accessTokenTTL:   cfg.AccessTokenTTL,
+		refreshTokenTTL:  cfg.RefreshTokenTTL,
+		passwordResetTTL: cfg.PasswordResetTTL,
+		verificationTTL:  cfg.EmailVerificationTTL,
+		magicLinkTTL:     cfg.MagicLinkTTL,
+		totpIssuer:       cfg.TOTPIssuer,
+		mfaChallengeTTL:  cfg.MFAChallengeTTL,
+		reauthWindow:     cfg.ReauthWindow,
 	}
 }
 
-func (s *authService) Register(req *models.RegisterRequest) (*models.AuthResponse, error) {
+func (s *authService) Register(req *models.RegisterRequest, client *models.ClientInfo) (*models.AuthResponse, error) {
 	exists, err := s.userRepo.ExistsByEmail(req.Email)
 	if err != nil {
 		return nil, err
@@ -64,40 +184,249 @@ func (s *authService) Register(req *models.RegisterRequest) (*models.AuthRespons
 		return nil, err
 	}
 
-	token, err := s.generateToken(user.ID)
-	if err != nil {
-		return nil, err
+	// Registration succeeds even if the mail cannot be sent; the user can
+	// ask for another link later.
+	if err := s.sendVerificationEmail(user); err != nil {
+		log.Printf("Failed to send verification email to %s: %v", user.Email, err)
 	}
 
-	return &models.AuthResponse{
-		User:  user.ToResponse(),
-		Token: token,
-	}, nil
+	return s.startSession(user, client)
 }
 
+func (s *authService) Login(req *models.LoginRequest, client *models.ClientInfo) (*models.AuthResponse, error) {
+	if err := s.loginGuard.Check(req.Email, client.IP); err != nil {
+		return nil, err
+	}
 
-func (s *authService) Login(req *models.LoginRequest) (*models.AuthResponse, error) {
 	user, err := s.userRepo.GetByEmail(req.Email)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, ErrInvalidCredentials
+			return nil, s.loginFailed(req.Email, client)
 		}
 		return nil, err
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
-		return nil, ErrInvalidCredentials
+		return nil, s.loginFailed(req.Email, client)
+	}
+
+	// With two-factor authentication the password alone is not a successful
+	// login; the counter is only reset once VerifyMFA accepts a code, so the
+	// password step cannot be used to clear failed code attempts.
+	if user.TOTPEnabledAt == nil {
+		if err := s.loginGuard.Succeed(req.Email); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.CompleteLogin(user, client)
+}
+
+// CompleteLogin finishes a login for a user whose first factor has been
+// checked, either by password or by another method such as OIDC. Users with
+// two-factor authentication get an MFA challenge instead of tokens.
+func (s *authService) CompleteLogin(user *models.User, client *models.ClientInfo) (*models.AuthResponse, error) {
+	if user.DisabledAt != nil {
+		return nil, ErrAccountDisabled
+	}
+	if user.TOTPEnabledAt != nil {
+		return s.newMFAChallenge(user)
 	}
 
-	token, err := s.generateToken(user.ID)
+	return s.startSession(user, client)
+}
+
+// loginFailed records a failed attempt and returns the error to report.
+func (s *authService) loginFailed(email string, client *models.ClientInfo) error {
+	if err := s.loginGuard.Fail(email, client.IP); err != nil {
+		return err
+	}
+	return ErrInvalidCredentials
+}
+
+func (s *authService) Refresh(req *models.RefreshTokenRequest, client *models.ClientInfo) (*models.AuthResponse, error) {
+	current, err := s.refreshTokenRepo.GetByHash(hashToken(req.RefreshToken))
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidRefresh
+		}
 		return nil, err
 	}
 
-	return &models.AuthResponse{
-		User:  user.ToResponse(),
-		Token: token,
-	}, nil
+	if current.RevokedAt != nil {
+		return nil, ErrInvalidRefresh
+	}
+	// A token that was already exchanged is being presented again: either the
+	// client or an attacker holds a stale copy, so kill the whole family.
+	if current.RotatedAt != nil {
+		if err := s.refreshTokenRepo.RevokeFamily(current.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshReused
+	}
+	if time.Now().After(current.ExpiresAt) {
+		return nil, ErrInvalidRefresh
+	}
+
+	user, err := s.GetUserByID(current.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user.DisabledAt != nil {
+		return nil, ErrAccountDisabled
+	}
+
+	active, err := s.sessionRepo.Touch(current.FamilyID, client.IP, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !active {
+		return nil, ErrInvalidRefresh
+	}
+
+	raw, next, err := s.newRefreshToken(user.ID, current.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.refreshTokenRepo.Rotate(current.ID, next); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if err := s.refreshTokenRepo.RevokeFamily(current.FamilyID); err != nil {
+				return nil, err
+			}
+			return nil, ErrRefreshReused
+		}
+		return nil, err
+	}
+
+	return s.buildAuthResponse(user, current.FamilyID, raw)
+}
+
+// Logout revokes the access token described by claims together with its
+// session and, when supplied, the refresh token family it was issued with.
+func (s *authService) Logout(claims *TokenClaims, req *models.LogoutRequest) error {
+	if err := s.revokedTokenRepo.Create(&models.RevokedToken{
+		JTI:       claims.TokenID,
+		UserID:    claims.UserID,
+		ExpiresAt: claims.ExpiresAt,
+	}); err != nil {
+		return err
+	}
+	s.denylist.Add(claims.TokenID, claims.ExpiresAt)
+
+	if claims.SessionID != uuid.Nil {
+		if err := s.RevokeSession(claims.SessionID, claims.UserID); err != nil && !errors.Is(err, ErrSessionNotFound) {
+			return err
+		}
+	}
+
+	if req.RefreshToken == "" {
+		return nil
+	}
+	refreshToken, err := s.refreshTokenRepo.GetByHash(hashToken(req.RefreshToken))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if refreshToken.UserID != claims.UserID {
+		return nil
+	}
+	return s.refreshTokenRepo.RevokeFamily(refreshToken.FamilyID)
+}
+
+// LogoutAll invalidates every session, access and refresh token the user
+// holds.
+func (s *authService) LogoutAll(userID uuid.UUID) error {
+	if err := s.userRepo.IncrementTokenVersion(userID); err != nil {
+		return err
+	}
+	if err := s.sessionRepo.RevokeAllForUser(userID); err != nil {
+		return err
+	}
+	return s.refreshTokenRepo.RevokeAllForUser(userID)
+}
+
+// ForgotPassword emails a reset link when the address belongs to a user. It
+// reports success for unknown addresses too, so the endpoint cannot be used
+// to discover which emails are registered.
+func (s *authService) ForgotPassword(req *models.ForgotPasswordRequest) error {
+	user, err := s.userRepo.GetByEmail(req.Email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	if err := s.userTokenRepo.InvalidateForUser(user.ID, models.TokenPurposePasswordReset); err != nil {
+		return err
+	}
+
+	raw, err := s.createUserToken(user.ID, models.TokenPurposePasswordReset, "", s.passwordResetTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(&mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your Mamonedz password",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nUse the link below to choose a new password. It expires in %s.\n\n%s/reset-password?token=%s\n\nIf you did not request this, you can ignore this email.\n",
+			user.Name, s.passwordResetTTL, s.appURL, raw,
+		),
+	})
+}
+
+// ResetPassword consumes a reset token, sets the new password and signs the
+// user out everywhere.
+func (s *authService) ResetPassword(req *models.ResetPasswordRequest) error {
+	token, err := s.userTokenRepo.Consume(hashToken(req.Token), models.TokenPurposePasswordReset)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidResetToken
+		}
+		return err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	if err := s.userRepo.UpdatePassword(token.UserID, string(hashedPassword)); err != nil {
+		return err
+	}
+
+	return s.LogoutAll(token.UserID)
+}
+
+// VerifyEmail confirms either the address given at registration or a new
+// address requested through ChangeEmail.
+func (s *authService) VerifyEmail(token string) error {
+	userToken, err := s.userTokenRepo.Consume(hashToken(token),
+		models.TokenPurposeEmailVerification, models.TokenPurposeEmailChange)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidVerifyToken
+		}
+		return err
+	}
+
+	if userToken.Purpose == models.TokenPurposeEmailChange {
+		return s.confirmEmailChange(userToken)
+	}
+	return s.userRepo.MarkEmailVerified(userToken.UserID)
+}
+
+func (s *authService) ResendVerification(userID uuid.UUID) error {
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt != nil {
+		return ErrAlreadyVerified
+	}
+	return s.sendVerificationEmail(user)
 }
 
 func (s *authService) GetUserByID(id uuid.UUID) (*models.User, error) {
@@ -111,43 +440,230 @@ func (s *authService) GetUserByID(id uuid.UUID) (*models.User, error) {
 	return user, nil
 }
 
-func (s *authService) ValidateToken(tokenString string) (*uuid.UUID, error) {
+func (s *authService) ValidateToken(tokenString string) (*TokenClaims, error) {
+	claims, userID, err := s.parseToken(tokenString, tokenTypeAccess)
+	if err != nil {
+		return nil, err
+	}
+
+	jti, ok := claims["jti"].(string)
+	if !ok || jti == "" {
+		return nil, ErrInvalidToken
+	}
+	if s.denylist.Contains(jti) {
+		return nil, ErrInvalidToken
+	}
+
+	var sessionID uuid.UUID
+	if sid, ok := claims["sid"].(string); ok {
+		if sessionID, err = uuid.Parse(sid); err != nil {
+			return nil, ErrInvalidToken
+		}
+		if s.denylist.Contains(sessionDenylistKey(sessionID)) {
+			return nil, ErrInvalidToken
+		}
+	}
+
+	version, _ := claims["tv"].(float64)
+	exp, err := claims.GetExpirationTime()
+	if err != nil || exp == nil {
+		return nil, ErrInvalidToken
+	}
+
+	return &TokenClaims{
+		UserID:       userID,
+		TokenID:      jti,
+		SessionID:    sessionID,
+		TokenVersion: int(version),
+		ExpiresAt:    exp.Time,
+	}, nil
+}
+
+// Authenticate validates a JWT or personal access token and loads its user.
+// The token version check piggybacks on the user lookup the middleware needs
+// anyway, so logout-all costs no extra query per request.
+func (s *authService) Authenticate(tokenString string) (*models.User, *TokenClaims, error) {
+	if strings.HasPrefix(tokenString, models.PersonalAccessTokenPrefix) {
+		return s.authenticatePersonalAccessToken(tokenString)
+	}
+
+	claims, err := s.ValidateToken(tokenString)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	user, err := s.GetUserByID(claims.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if user.TokenVersion != claims.TokenVersion {
+		return nil, nil, ErrInvalidToken
+	}
+	if user.DisabledAt != nil {
+		return nil, nil, ErrAccountDisabled
+	}
+
+	return user, claims, nil
+}
+
+func (s *authService) authenticatePersonalAccessToken(raw string) (*models.User, *TokenClaims, error) {
+	token, err := s.patService.Authenticate(raw)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	user, err := s.GetUserByID(token.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if user.DisabledAt != nil {
+		return nil, nil, ErrAccountDisabled
+	}
+
+	claims := &TokenClaims{
+		UserID:  user.ID,
+		TokenID: token.ID.String(),
+		Scopes:  token.Scopes,
+	}
+	if token.ExpiresAt != nil {
+		claims.ExpiresAt = *token.ExpiresAt
+	}
+	return user, claims, nil
+}
+
+// parseToken verifies the signature and expiry of a JWT issued by this
+// service and checks that it is of the expected type, so that e.g. an MFA
+// challenge token can never be used as an access token.
+func (s *authService) parseToken(tokenString, tokenType string) (jwt.MapClaims, uuid.UUID, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		kid, _ := token.Header["kid"].(string)
+		key, err := s.keyring.Lookup(kid)
+		if err != nil {
+			return nil, err
+		}
+		if token.Method.Alg() != key.method.Alg() {
 			return nil, ErrInvalidToken
 		}
-		return []byte(s.jwtSecret), nil
-	})
+		return key.public, nil
+	}, jwt.WithIssuer(s.issuer), jwt.WithValidMethods([]string{AlgorithmRS256, AlgorithmEdDSA}))
 
 	if err != nil || !token.Valid {
-		return nil, ErrInvalidToken
+		return nil, uuid.Nil, ErrInvalidToken
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
-		return nil, ErrInvalidToken
+		return nil, uuid.Nil, ErrInvalidToken
+	}
+
+	if typ, _ := claims["typ"].(string); typ != tokenType {
+		return nil, uuid.Nil, ErrInvalidToken
 	}
 
 	userIDStr, ok := claims["user_id"].(string)
 	if !ok {
-		return nil, ErrInvalidToken
+		return nil, uuid.Nil, ErrInvalidToken
 	}
 
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
-		return nil, ErrInvalidToken
+		return nil, uuid.Nil, ErrInvalidToken
+	}
+
+	return claims, userID, nil
+}
+
+func (s *authService) buildAuthResponse(user *models.User, sessionID uuid.UUID, refreshToken string) (*models.AuthResponse, error) {
+	token, err := s.generateToken(user, sessionID)
+	if err != nil {
+		return nil, err
 	}
 
-	return &userID, nil
+	return &models.AuthResponse{
+		User:         user.ToResponse(),
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(s.accessTokenTTL.Seconds()),
+	}, nil
 }
 
-func (s *authService) generateToken(userID uuid.UUID) (string, error) {
-	claims := jwt.MapClaims{
-		"user_id": userID.String(),
-		"exp":     time.Now().Add(24 * time.Hour).Unix(),
+func (s *authService) newRefreshToken(userID, familyID uuid.UUID) (string, *models.RefreshToken, error) {
+	raw, hash, err := generateOpaqueToken()
+	if err != nil {
+		return "", nil, err
+	}
+	return raw, &models.RefreshToken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(s.refreshTokenTTL),
+	}, nil
+}
+
+func (s *authService) sendVerificationEmail(user *models.User) error {
+	if err := s.userTokenRepo.InvalidateForUser(user.ID, models.TokenPurposeEmailVerification); err != nil {
+		return err
+	}
+
+	raw, err := s.createUserToken(user.ID, models.TokenPurposeEmailVerification, "", s.verificationTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(&mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your Mamonedz email",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nPlease confirm your email address by opening the link below. It expires in %s.\n\n%s/verify-email?token=%s\n",
+			user.Name, s.verificationTTL, s.appURL, raw,
+		),
+	})
+}
+
+func (s *authService) createUserToken(userID uuid.UUID, purpose, email string, ttl time.Duration) (string, error) {
+	raw, hash, err := generateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	err = s.userTokenRepo.Create(&models.UserToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: hash,
+		Email:     email,
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	if err != nil {
+		return "", err
+	}
+	return raw, nil
+}
+
+func (s *authService) generateToken(user *models.User, sessionID uuid.UUID) (string, error) {
+	return s.signToken(jwt.MapClaims{
+		"typ":     tokenTypeAccess,
+		"user_id": user.ID.String(),
+		"jti":     uuid.NewString(),
+		"sid":     sessionID.String(),
+		"tv":      user.TokenVersion,
+		"exp":     time.Now().Add(s.accessTokenTTL).Unix(),
 		"iat":     time.Now().Unix(),
+	})
+}
+
+func (s *authService) signToken(claims jwt.MapClaims) (string, error) {
+	key, err := s.keyring.Current()
+	if err != nil {
+		return "", err
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.jwtSecret))
+	claims["iss"] = s.issuer
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.kid
+	return token.SignedString(key.private)
+}
+
+// JWKS publishes the public keys that verify access tokens so other services
+// can validate them without sharing a secret.
+func (s *authService) JWKS() (*models.JWKS, error) {
+	return s.keyring.JWKS()
 }
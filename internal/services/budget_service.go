@@ -0,0 +1,266 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"mamonedz/internal/mailer"
+	"mamonedz/internal/models"
+	"mamonedz/internal/repository"
+	"mamonedz/pkg/money"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrBudgetNotFound = errors.New("budget not found")
+	ErrBudgetExists   = errors.New("budget already exists")
+	ErrInvalidMonth   = errors.New("invalid month format, use YYYY-MM")
+)
+
+type BudgetService interface {
+	Create(userID uuid.UUID, req *models.CreateBudgetRequest) (*models.Budget, error)
+	GetAll(userID uuid.UUID) ([]models.Budget, error)
+	Update(id, userID uuid.UUID, req *models.UpdateBudgetRequest) (*models.Budget, error)
+	Delete(id, userID uuid.UUID) error
+	GetStatus(userID uuid.UUID, month time.Time) ([]models.BudgetStatus, error)
+	GetAlerts(userID uuid.UUID, limit, offset int) ([]models.BudgetAlert, int64, error)
+	CheckAlerts(userID uuid.UUID, date time.Time) error
+}
+
+type budgetService struct {
+	repo         repository.BudgetRepository
+	categoryRepo repository.CategoryRepository
+	userRepo     repository.UserRepository
+	mailer       mailer.Mailer
+	appURL       string
+}
+
+func NewBudgetService(repo repository.BudgetRepository, categoryRepo repository.CategoryRepository, userRepo repository.UserRepository, mail mailer.Mailer, appURL string) BudgetService {
+	return &budgetService{repo: repo, categoryRepo: categoryRepo, userRepo: userRepo, mailer: mail, appURL: appURL}
+}
+
+func (s *budgetService) Create(userID uuid.UUID, req *models.CreateBudgetRequest) (*models.Budget, error) {
+	if req.CategoryID != nil {
+		if _, err := resolveCategory(s.categoryRepo, userID, models.TransactionTypeExpense, req.CategoryID, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	startMonth := monthOf(time.Now())
+	if req.StartMonth != "" {
+		month, err := ParseMonth(req.StartMonth)
+		if err != nil {
+			return nil, err
+		}
+		startMonth = month
+	}
+
+	budget := &models.Budget{
+		UserID:     userID,
+		CategoryID: req.CategoryID,
+		Amount:     req.Amount,
+		Rollover:   req.Rollover,
+		StartMonth: startMonth,
+	}
+	created, err := s.repo.Create(budget)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return nil, ErrBudgetExists
+	}
+	return budget, nil
+}
+
+func (s *budgetService) GetAll(userID uuid.UUID) ([]models.Budget, error) {
+	return s.repo.GetAllByUser(userID)
+}
+
+func (s *budgetService) Update(id, userID uuid.UUID, req *models.UpdateBudgetRequest) (*models.Budget, error) {
+	budget, err := s.repo.GetByID(id, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrBudgetNotFound
+		}
+		return nil, err
+	}
+
+	if req.Amount != nil {
+		budget.Amount = *req.Amount
+	}
+	if req.Rollover != nil {
+		budget.Rollover = *req.Rollover
+	}
+
+	budget.UpdatedAt = time.Now()
+
+	if err := s.repo.Update(budget); err != nil {
+		return nil, err
+	}
+	return budget, nil
+}
+
+func (s *budgetService) Delete(id, userID uuid.UUID) error {
+	if err := s.repo.Delete(id, userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrBudgetNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// GetStatus reports every budget that applies in month.
+func (s *budgetService) GetStatus(userID uuid.UUID, month time.Time) ([]models.BudgetStatus, error) {
+	month = monthOf(month)
+
+	budgets, err := s.repo.GetAllByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	categories, err := s.categoryRepo.GetAllByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[uuid.UUID]string, len(categories))
+	for _, c := range categories {
+		names[c.ID] = c.Name
+	}
+
+	statuses := []models.BudgetStatus{}
+	for i := range budgets {
+		if budgets[i].StartMonth.After(month) {
+			continue
+		}
+		status, err := s.status(&budgets[i], month)
+		if err != nil {
+			return nil, err
+		}
+		if status.CategoryID != nil {
+			status.Category = names[*status.CategoryID]
+		}
+		statuses = append(statuses, *status)
+	}
+	return statuses, nil
+}
+
+func (s *budgetService) GetAlerts(userID uuid.UUID, limit, offset int) ([]models.BudgetAlert, int64, error) {
+	return s.repo.GetAlerts(userID, limit, offset)
+}
+
+// CheckAlerts raises an alert for every budget threshold the user's spending
+// has reached in the month of date, unless it was raised before. Each new
+// alert is emailed to the user in the background once it is stored, so the
+// request that recorded the expense does not wait for the mail server.
+func (s *budgetService) CheckAlerts(userID uuid.UUID, date time.Time) error {
+	month := monthOf(date)
+	statuses, err := s.GetStatus(userID, month)
+	if err != nil {
+		return err
+	}
+
+	for _, status := range statuses {
+		for _, threshold := range models.BudgetAlertThresholds {
+			if status.PercentUsed < float64(threshold) {
+				continue
+			}
+			alert := &models.BudgetAlert{
+				UserID:    userID,
+				BudgetID:  status.BudgetID,
+				Month:     month,
+				Threshold: threshold,
+				Budgeted:  status.Budgeted,
+				Spent:     status.Spent,
+			}
+			created, err := s.repo.CreateAlert(alert)
+			if err != nil {
+				return err
+			}
+			if created {
+				go s.notify(alert, &status)
+			}
+		}
+	}
+	return nil
+}
+
+// status computes a budget's state in month. With rollover, the unspent part
+// of every earlier month since the budget started carries forward;
+// overspending does not.
+func (s *budgetService) status(budget *models.Budget, month time.Time) (*models.BudgetStatus, error) {
+	from := month
+	if budget.Rollover {
+		from = budget.StartMonth
+	}
+	spending, err := s.repo.GetMonthlySpending(budget.UserID, budget.CategoryID, from, month.AddDate(0, 1, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	var carry money.Amount
+	for m := from; m.Before(month); m = m.AddDate(0, 1, 0) {
+		carry += budget.Amount - spending[m.Format("2006-01")]
+		if carry < 0 {
+			carry = 0
+		}
+	}
+
+	status := &models.BudgetStatus{
+		BudgetID:   budget.ID,
+		CategoryID: budget.CategoryID,
+		Month:      month.Format("2006-01"),
+		Amount:     budget.Amount,
+		Rollover:   carry,
+		Budgeted:   budget.Amount + carry,
+		Spent:      spending[month.Format("2006-01")],
+	}
+	status.Remaining = status.Budgeted - status.Spent
+	if status.Budgeted > 0 {
+		status.PercentUsed = math.Round(status.Spent.Float64()/status.Budgeted.Float64()*10000) / 100
+	}
+	return status, nil
+}
+
+func (s *budgetService) notify(alert *models.BudgetAlert, status *models.BudgetStatus) {
+	user, err := s.userRepo.GetByID(alert.UserID)
+	if err != nil {
+		log.Printf("Failed to load user for budget alert %s: %v", alert.ID, err)
+		return
+	}
+
+	name := "your overall budget"
+	if status.Category != "" {
+		name = "your " + status.Category + " budget"
+	}
+	err = s.mailer.Send(&mailer.Message{
+		To:      user.Email,
+		Subject: fmt.Sprintf("You have used %d%% of %s", alert.Threshold, name),
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nYou have spent %s %s of %s %s budgeted for %s (%.2f%%).\n\n%s/budgets\n",
+			user.Name, alert.Spent, user.BaseCurrency, alert.Budgeted, user.BaseCurrency, status.Month, status.PercentUsed, s.appURL,
+		),
+	})
+	if err != nil {
+		log.Printf("Failed to send budget alert %s: %v", alert.ID, err)
+	}
+}
+
+// ParseMonth reads a YYYY-MM month as its first day.
+func ParseMonth(value string) (time.Time, error) {
+	month, err := time.Parse("2006-01", value)
+	if err != nil {
+		return time.Time{}, ErrInvalidMonth
+	}
+	return month, nil
+}
+
+// monthOf returns the first day of t's month, as midnight UTC like dates
+// read from the database.
+func monthOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
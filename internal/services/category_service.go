@@ -0,0 +1,237 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"mamonedz/internal/models"
+	"mamonedz/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCategoryNotFound     = errors.New("category not found")
+	ErrCategoryExists       = errors.New("category already exists")
+	ErrCategoryInUse        = errors.New("category has expenses, choose a category to move them to")
+	ErrInvalidReassignment  = errors.New("expenses must be moved to a different category")
+	ErrParentNotFound       = errors.New("parent category not found")
+	ErrInvalidParent        = errors.New("a category cannot be moved under itself or its subcategories")
+	ErrCategoryTypeMismatch = errors.New("categories can only be combined with categories of the same type")
+)
+
+type CategoryService interface {
+	Create(userID uuid.UUID, req *models.CreateCategoryRequest) (*models.Category, error)
+	GetAll(userID uuid.UUID, categoryType string) ([]models.Category, error)
+	Update(id, userID uuid.UUID, req *models.UpdateCategoryRequest) (*models.Category, error)
+	Delete(id, userID uuid.UUID, reassignTo *uuid.UUID) error
+}
+
+type categoryService struct {
+	repo repository.CategoryRepository
+}
+
+func NewCategoryService(repo repository.CategoryRepository) CategoryService {
+	return &categoryService{repo: repo}
+}
+
+func (s *categoryService) Create(userID uuid.UUID, req *models.CreateCategoryRequest) (*models.Category, error) {
+	categoryType := req.Type
+	if categoryType == "" {
+		categoryType = models.TransactionTypeExpense
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if err := s.checkNameFree(name, categoryType, userID, uuid.Nil); err != nil {
+		return nil, err
+	}
+
+	if req.ParentID != nil {
+		parent, err := s.get(*req.ParentID, userID)
+		if err != nil {
+			if errors.Is(err, ErrCategoryNotFound) {
+				return nil, ErrParentNotFound
+			}
+			return nil, err
+		}
+		if parent.Type != categoryType {
+			return nil, ErrCategoryTypeMismatch
+		}
+	}
+
+	category := &models.Category{
+		UserID:   userID,
+		Type:     categoryType,
+		ParentID: req.ParentID,
+		Name:     name,
+		Color:    req.Color,
+		Icon:     req.Icon,
+	}
+	if req.SortOrder != nil {
+		category.SortOrder = *req.SortOrder
+	} else {
+		existing, err := s.GetAll(userID, categoryType)
+		if err != nil {
+			return nil, err
+		}
+		category.SortOrder = len(existing)
+	}
+
+	if err := s.repo.Create(category); err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+// GetAll lists the user's categories, optionally only those of one
+// transaction type.
+func (s *categoryService) GetAll(userID uuid.UUID, categoryType string) ([]models.Category, error) {
+	categories, err := s.repo.GetAllByUser(userID)
+	if err != nil || categoryType == "" {
+		return categories, err
+	}
+
+	filtered := make([]models.Category, 0, len(categories))
+	for _, c := range categories {
+		if c.Type == categoryType {
+			filtered = append(filtered, c)
+		}
+	}
+	return filtered, nil
+}
+
+// Update changes a category in place. Expenses reference it by ID, so a
+// rename carries over to the whole history.
+func (s *categoryService) Update(id, userID uuid.UUID, req *models.UpdateCategoryRequest) (*models.Category, error) {
+	category, err := s.get(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name != category.Name {
+			if err := s.checkNameFree(name, category.Type, userID, category.ID); err != nil {
+				return nil, err
+			}
+			category.Name = name
+		}
+	}
+	if req.ParentID != nil {
+		if err := s.checkParent(category, *req.ParentID); err != nil {
+			return nil, err
+		}
+		category.ParentID = req.ParentID
+	} else if req.MoveToRoot {
+		category.ParentID = nil
+	}
+	if req.Color != nil {
+		category.Color = *req.Color
+	}
+	if req.Icon != nil {
+		category.Icon = *req.Icon
+	}
+	if req.SortOrder != nil {
+		category.SortOrder = *req.SortOrder
+	}
+
+	category.UpdatedAt = time.Now()
+
+	if err := s.repo.Update(category); err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+// Delete removes a category. Categories that still have expenses can only be
+// deleted by moving those expenses to reassignTo.
+func (s *categoryService) Delete(id, userID uuid.UUID, reassignTo *uuid.UUID) error {
+	category, err := s.get(id, userID)
+	if err != nil {
+		return err
+	}
+
+	var target *models.Category
+	if reassignTo != nil {
+		if *reassignTo == id {
+			return ErrInvalidReassignment
+		}
+		if target, err = s.get(*reassignTo, userID); err != nil {
+			return err
+		}
+		if target.Type != category.Type {
+			return ErrCategoryTypeMismatch
+		}
+	}
+
+	deleted, err := s.repo.Delete(id, userID, target)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrCategoryNotFound
+		}
+		return err
+	}
+	if !deleted {
+		return ErrCategoryInUse
+	}
+	return nil
+}
+
+func (s *categoryService) get(id, userID uuid.UUID) (*models.Category, error) {
+	category, err := s.repo.GetByID(id, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCategoryNotFound
+		}
+		return nil, err
+	}
+	return category, nil
+}
+
+// checkParent makes sure parentID exists and is not category itself or one
+// of its descendants, which would create a cycle. Two concurrent moves can
+// still create one between them, so the walk up from parentID gives up after
+// visiting every category once rather than looping forever.
+func (s *categoryService) checkParent(category *models.Category, parentID uuid.UUID) error {
+	categories, err := s.repo.GetAllByUser(category.UserID)
+	if err != nil {
+		return err
+	}
+	parents := make(map[uuid.UUID]*uuid.UUID, len(categories))
+	types := make(map[uuid.UUID]string, len(categories))
+	for _, c := range categories {
+		parents[c.ID] = c.ParentID
+		types[c.ID] = c.Type
+	}
+
+	if _, ok := parents[parentID]; !ok {
+		return ErrParentNotFound
+	}
+	if types[parentID] != category.Type {
+		return ErrCategoryTypeMismatch
+	}
+	steps := 0
+	for id := &parentID; id != nil; id = parents[*id] {
+		if *id == category.ID || steps > len(categories) {
+			return ErrInvalidParent
+		}
+		steps++
+	}
+	return nil
+}
+
+func (s *categoryService) checkNameFree(name, categoryType string, userID, except uuid.UUID) error {
+	existing, err := s.repo.GetByName(name, categoryType, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if existing.ID != except {
+		return ErrCategoryExists
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package services
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"mamonedz/internal/models"
+	"mamonedz/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidRatesCSV = errors.New("invalid exchange rates CSV")
+
+// CurrencyService manages exchange rates and users' base currencies. Every
+// change converts the affected transactions again, so stored converted
+// amounts always follow the current rates.
+type CurrencyService interface {
+	SetBaseCurrency(userID uuid.UUID, currency string) (*models.UserResponse, error)
+	CreateRate(req *models.CreateExchangeRateRequest) (*models.ExchangeRate, error)
+	ImportRates(r io.Reader) (*models.ImportRatesResult, error)
+	ImportRatesFile(path string) (*models.ImportRatesResult, error)
+	GetRates(filter *models.ExchangeRateFilter) ([]models.ExchangeRate, int64, error)
+}
+
+type currencyService struct {
+	rateRepo    repository.ExchangeRateRepository
+	userRepo    repository.UserRepository
+	expenseRepo repository.ExpenseRepository
+	ledgerRepo  repository.LedgerRepository
+}
+
+func NewCurrencyService(rateRepo repository.ExchangeRateRepository, userRepo repository.UserRepository, expenseRepo repository.ExpenseRepository, ledgerRepo repository.LedgerRepository) CurrencyService {
+	return &currencyService{rateRepo: rateRepo, userRepo: userRepo, expenseRepo: expenseRepo, ledgerRepo: ledgerRepo}
+}
+
+func (s *currencyService) SetBaseCurrency(userID uuid.UUID, currency string) (*models.UserResponse, error) {
+	currency = strings.ToUpper(currency)
+	if err := s.userRepo.SetBaseCurrency(userID, currency); err != nil {
+		return nil, err
+	}
+	ledger, err := s.ledgerRepo.GetPersonal(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.expenseRepo.ConvertByLedger(ledger.ID); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return user.ToResponse(), nil
+}
+
+func (s *currencyService) CreateRate(req *models.CreateExchangeRateRequest) (*models.ExchangeRate, error) {
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		return nil, ErrInvalidDate
+	}
+
+	rate := models.ExchangeRate{
+		BaseCurrency:  strings.ToUpper(req.BaseCurrency),
+		QuoteCurrency: strings.ToUpper(req.QuoteCurrency),
+		Date:          date,
+		Rate:          req.Rate,
+	}
+	if err := s.store([]models.ExchangeRate{rate}); err != nil {
+		return nil, err
+	}
+	return &rate, nil
+}
+
+// ImportRates reads rates as CSV rows of date, base currency, quote currency
+// and rate, e.g. "2024-05-01,USD,IDR,16050". A header row is skipped. Nothing
+// is stored unless every row is valid.
+func (s *currencyService) ImportRates(r io.Reader) (*models.ImportRatesResult, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 4
+	reader.TrimLeadingSpace = true
+
+	var rates []models.ExchangeRate
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidRatesCSV, err)
+		}
+		if line == 1 && strings.EqualFold(record[0], "date") {
+			continue
+		}
+
+		rate, err := parseRateRecord(record)
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidRatesCSV, line, err)
+		}
+		rates = append(rates, *rate)
+	}
+
+	if err := s.store(rates); err != nil {
+		return nil, err
+	}
+	return &models.ImportRatesResult{Imported: len(rates)}, nil
+}
+
+func (s *currencyService) ImportRatesFile(path string) (*models.ImportRatesResult, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return s.ImportRates(f)
+}
+
+func (s *currencyService) GetRates(filter *models.ExchangeRateFilter) ([]models.ExchangeRate, int64, error) {
+	filter.BaseCurrency = strings.ToUpper(filter.BaseCurrency)
+	filter.QuoteCurrency = strings.ToUpper(filter.QuoteCurrency)
+	return s.rateRepo.GetAll(filter)
+}
+
+// store saves the rates and converts again the transactions they can
+// affect: those in each pair's currencies dated on or after the earliest new
+// rate for it.
+func (s *currencyService) store(rates []models.ExchangeRate) error {
+	if err := s.rateRepo.Upsert(rates); err != nil {
+		return err
+	}
+
+	from := make(map[[2]string]time.Time)
+	for _, rate := range rates {
+		pair := [2]string{rate.BaseCurrency, rate.QuoteCurrency}
+		if pair[0] > pair[1] {
+			pair[0], pair[1] = pair[1], pair[0]
+		}
+		if date, ok := from[pair]; !ok || rate.Date.Before(date) {
+			from[pair] = rate.Date
+		}
+	}
+	for pair, date := range from {
+		if err := s.expenseRepo.ConvertPair(pair[0], pair[1], date); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseRateRecord(record []string) (*models.ExchangeRate, error) {
+	date, err := time.Parse("2006-01-02", strings.TrimSpace(record[0]))
+	if err != nil {
+		return nil, errors.New("date must be YYYY-MM-DD")
+	}
+	base := strings.ToUpper(strings.TrimSpace(record[1]))
+	quote := strings.ToUpper(strings.TrimSpace(record[2]))
+	if !isCurrencyCode(base) || !isCurrencyCode(quote) || base == quote {
+		return nil, errors.New("currencies must be two different ISO 4217 codes")
+	}
+	rate, err := strconv.ParseFloat(strings.TrimSpace(record[3]), 64)
+	if err != nil || rate <= 0 {
+		return nil, errors.New("rate must be a positive number")
+	}
+
+	return &models.ExchangeRate{
+		BaseCurrency:  base,
+		QuoteCurrency: quote,
+		Date:          date,
+		Rate:          rate,
+	}, nil
+}
+
+func isCurrencyCode(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
@@ -2,6 +2,10 @@ package services
 
 import (
 	"errors"
+	"log"
+	"math"
+	"sort"
+	"strings"
 	"time"
 
 	"mamonedz/internal/models"
@@ -15,6 +19,8 @@ var (
 	ErrExpenseNotFound = errors.New("expense not found")
 	ErrInvalidCategory = errors.New("invalid category")
 	ErrInvalidDate     = errors.New("invalid date format, use YYYY-MM-DD")
+	ErrInvalidAccount  = errors.New("invalid account")
+	ErrAccountCurrency = errors.New("currency does not match the account")
 )
 
 type ExpenseService interface {
@@ -23,20 +29,53 @@ type ExpenseService interface {
 	GetAll(filter *models.ExpenseFilter) ([]models.Expense, int64, error)
 	Update(id, userID uuid.UUID, req *models.UpdateExpenseRequest) (*models.Expense, error)
 	Delete(id, userID uuid.UUID) error
-	GetStats(userID uuid.UUID, period string) (*models.ExpenseStats, error)
+	GetStats(userID uuid.UUID, query *models.StatsQuery) (*models.ExpenseStats, error)
 }
 
 type expenseService struct {
-	repo repository.ExpenseRepository
+	repo         repository.ExpenseRepository
+	categoryRepo repository.CategoryRepository
+	tagRepo      repository.TagRepository
+	accountRepo  repository.AccountRepository
+	userRepo     repository.UserRepository
+	ledgerRepo   repository.LedgerRepository
+	budgets      BudgetService
 }
 
-func NewExpenseService(repo repository.ExpenseRepository) ExpenseService {
-	return &expenseService{repo: repo}
+func NewExpenseService(repo repository.ExpenseRepository, categoryRepo repository.CategoryRepository, tagRepo repository.TagRepository, accountRepo repository.AccountRepository, userRepo repository.UserRepository, ledgerRepo repository.LedgerRepository, budgets BudgetService) ExpenseService {
+	return &expenseService{repo: repo, categoryRepo: categoryRepo, tagRepo: tagRepo, accountRepo: accountRepo, userRepo: userRepo, ledgerRepo: ledgerRepo, budgets: budgets}
 }
 
 func (s *expenseService) Create(userID uuid.UUID, req *models.CreateExpenseRequest) (*models.Expense, error) {
-	if !models.ValidCategories[req.Category] {
-		return nil, ErrInvalidCategory
+	txType := req.Type
+	if txType == "" {
+		txType = models.TransactionTypeExpense
+	}
+
+	ledger, err := resolveLedger(s.ledgerRepo, userID, req.LedgerID, true)
+	if err != nil {
+		return nil, err
+	}
+	paidBy := userID
+	if req.PaidBy != nil {
+		if err := s.checkPayer(ledger.ID, *req.PaidBy); err != nil {
+			return nil, err
+		}
+		paidBy = *req.PaidBy
+	}
+
+	category, err := resolveCategory(s.categoryRepo, userID, txType, req.CategoryID, req.Category)
+	if err != nil {
+		return nil, err
+	}
+
+	currency := req.Currency
+	if currency == "" && req.AccountID == nil {
+		currency = ledger.BaseCurrency
+	}
+	currency, err = resolveCurrency(s.accountRepo, s.userRepo, userID, req.AccountID, currency)
+	if err != nil {
+		return nil, err
 	}
 
 	date, err := time.Parse("2006-01-02", req.Date)
@@ -44,17 +83,30 @@ func (s *expenseService) Create(userID uuid.UUID, req *models.CreateExpenseReque
 		return nil, ErrInvalidDate
 	}
 
+	tags, err := s.tagRepo.FindOrCreate(userID, NormalizeTags(req.Tags))
+	if err != nil {
+		return nil, err
+	}
+
 	expense := &models.Expense{
-		UserID:   userID,
-		Amount:   req.Amount,
-		Category: req.Category,
-		Date:     date,
-		Note:     req.Note,
+		UserID:     userID,
+		LedgerID:   ledger.ID,
+		PaidBy:     paidBy,
+		Type:       txType,
+		Amount:     req.Amount,
+		Currency:   currency,
+		CategoryID: category.ID,
+		Category:   category.Name,
+		AccountID:  req.AccountID,
+		Date:       date,
+		Note:       req.Note,
+		Tags:       tags,
 	}
 
 	if err := s.repo.Create(expense); err != nil {
 		return nil, err
 	}
+	checkBudgets(s.budgets, expense)
 
 	return expense, nil
 }
@@ -70,27 +122,64 @@ func (s *expenseService) GetByID(id, userID uuid.UUID) (*models.Expense, error)
 	return expense, nil
 }
 
+// GetAll lists the transactions of the filter's ledger, by default the
+// user's personal ledger.
 func (s *expenseService) GetAll(filter *models.ExpenseFilter) ([]models.Expense, int64, error) {
+	ledger, err := resolveLedger(s.ledgerRepo, filter.UserID, filter.LedgerID, false)
+	if err != nil {
+		return nil, 0, err
+	}
+	filter.LedgerID = &ledger.ID
 	return s.repo.GetAll(filter)
 }
 
+// Update lets any member with a write role change a transaction. Category,
+// account and tags keep referring to the recorder's own.
 func (s *expenseService) Update(id, userID uuid.UUID, req *models.UpdateExpenseRequest) (*models.Expense, error) {
-	expense, err := s.repo.GetByID(id, userID)
+	expense, err := s.writable(id, userID)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, ErrExpenseNotFound
-		}
 		return nil, err
 	}
+	owner := expense.UserID
 
 	if req.Amount != nil {
 		expense.Amount = *req.Amount
 	}
-	if req.Category != nil {
-		if !models.ValidCategories[*req.Category] {
-			return nil, ErrInvalidCategory
+	if req.CategoryID != nil || req.Category != nil {
+		var name string
+		if req.Category != nil {
+			name = *req.Category
+		}
+		category, err := resolveCategory(s.categoryRepo, owner, expense.Type, req.CategoryID, name)
+		if err != nil {
+			return nil, err
+		}
+		expense.CategoryID = category.ID
+		expense.Category = category.Name
+	}
+	if req.Currency != nil {
+		expense.Currency = strings.ToUpper(*req.Currency)
+	}
+	if req.AccountID != nil {
+		expense.AccountID = req.AccountID
+	}
+	if req.RemoveAccount {
+		expense.AccountID = nil
+	}
+	if expense.AccountID != nil && (req.AccountID != nil || req.Currency != nil) {
+		account, err := getAccount(s.accountRepo, *expense.AccountID, owner)
+		if err != nil {
+			return nil, err
 		}
-		expense.Category = *req.Category
+		if expense.Currency != account.Currency {
+			return nil, ErrAccountCurrency
+		}
+	}
+	if req.PaidBy != nil {
+		if err := s.checkPayer(expense.LedgerID, *req.PaidBy); err != nil {
+			return nil, err
+		}
+		expense.PaidBy = *req.PaidBy
 	}
 	if req.Date != nil {
 		date, err := time.Parse("2006-01-02", *req.Date)
@@ -102,32 +191,75 @@ func (s *expenseService) Update(id, userID uuid.UUID, req *models.UpdateExpenseR
 	if req.Note != nil {
 		expense.Note = req.Note
 	}
+	if req.Tags != nil {
+		tags, err := s.tagRepo.FindOrCreate(owner, NormalizeTags(*req.Tags))
+		if err != nil {
+			return nil, err
+		}
+		expense.Tags = tags
+	}
 
 	expense.UpdatedAt = time.Now()
 
-	if err := s.repo.Update(expense); err != nil {
+	if err := s.repo.Update(expense, userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrExpenseNotFound
+		}
 		return nil, err
 	}
+	checkBudgets(s.budgets, expense)
 
 	return expense, nil
 }
 
 func (s *expenseService) Delete(id, userID uuid.UUID) error {
-	_, err := s.repo.GetByID(id, userID)
-	if err != nil {
+	if _, err := s.writable(id, userID); err != nil {
+		return err
+	}
+	if err := s.repo.Delete(id, userID); err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return ErrExpenseNotFound
 		}
 		return err
 	}
-	return s.repo.Delete(id, userID)
+	return nil
+}
+
+// writable returns the transaction if the user may change it.
+func (s *expenseService) writable(id, userID uuid.UUID) (*models.Expense, error) {
+	expense, err := s.GetByID(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	member, err := s.ledgerRepo.GetMember(expense.LedgerID, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrExpenseNotFound
+		}
+		return nil, err
+	}
+	if !canWrite(member.Role) {
+		return nil, ErrLedgerForbidden
+	}
+	return expense, nil
+}
+
+// checkPayer makes sure the payer is a member of the ledger.
+func (s *expenseService) checkPayer(ledgerID, paidBy uuid.UUID) error {
+	if _, err := s.ledgerRepo.GetMember(ledgerID, paidBy); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidPayer
+		}
+		return err
+	}
+	return nil
 }
 
-func (s *expenseService) GetStats(userID uuid.UUID, period string) (*models.ExpenseStats, error) {
+func (s *expenseService) GetStats(userID uuid.UUID, query *models.StatsQuery) (*models.ExpenseStats, error) {
 	now := time.Now()
 	var startDate, endDate time.Time
 
-	switch period {
+	switch query.Period {
 	case "day":
 		startDate = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
 		endDate = startDate.AddDate(0, 0, 1).Add(-time.Second)
@@ -146,5 +278,269 @@ func (s *expenseService) GetStats(userID uuid.UUID, period string) (*models.Expe
 		endDate = startDate.AddDate(0, 1, 0).Add(-time.Second)
 	}
 
-	return s.repo.GetStats(userID, &startDate, &endDate)
+	txType := query.Type
+	if txType == "" {
+		txType = models.TransactionTypeExpense
+	}
+
+	ledger, err := resolveLedger(s.ledgerRepo, userID, query.LedgerID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := s.repo.GetStats(ledger.ID, userID, txType, &startDate, &endDate)
+	if err != nil {
+		return nil, err
+	}
+	stats.Type = txType
+	stats.Currency = ledger.BaseCurrency
+
+	totals, err := s.repo.GetTypeTotals(ledger.ID, userID, &startDate, &endDate)
+	if err != nil {
+		return nil, err
+	}
+	stats.IncomeTotal = totals[models.TransactionTypeIncome]
+	stats.ExpenseTotal = totals[models.TransactionTypeExpense]
+	stats.Net = stats.IncomeTotal - stats.ExpenseTotal
+	if stats.IncomeTotal > 0 {
+		stats.SavingsRate = math.Round(stats.Net.Float64()/stats.IncomeTotal.Float64()*10000) / 100
+	}
+
+	var categories []models.Category
+	all, err := s.categoryRepo.GetAllByLedger(ledger.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range all {
+		if c.Type == txType {
+			categories = append(categories, c)
+		}
+	}
+	stats.ByCategory = buildCategoryTree(mergeCategories(categories, stats.ByCategory, userID))
+
+	if query.GroupBy == "tag" {
+		if stats.ByTag, err = s.repo.GetTagStats(ledger.ID, userID, txType, &startDate, &endDate); err != nil {
+			return nil, err
+		}
+		if stats.ByTag == nil {
+			stats.ByTag = []models.TagStats{}
+		}
+	}
+
+	return stats, nil
+}
+
+// mergeCategories folds categories with the same name into one, so that a
+// shared ledger reports each category once although every member records
+// transactions in their own. The user's category is kept where there is
+// one, otherwise that of the member who joined first, and the hierarchy is
+// the kept category's. Totals of the folded categories move onto the kept
+// one.
+func mergeCategories(categories []models.Category, totals []models.CategoryStats, userID uuid.UUID) ([]models.Category, []models.CategoryStats) {
+	sort.SliceStable(categories, func(i, j int) bool {
+		return categories[i].UserID == userID && categories[j].UserID != userID
+	})
+
+	kept := make(map[string]uuid.UUID, len(categories))
+	alias := make(map[uuid.UUID]uuid.UUID, len(categories))
+	var merged []models.Category
+	for _, c := range categories {
+		if id, ok := kept[c.Name]; ok {
+			alias[c.ID] = id
+			continue
+		}
+		kept[c.Name] = c.ID
+		alias[c.ID] = c.ID
+		merged = append(merged, c)
+	}
+	for i, c := range merged {
+		if c.ParentID != nil {
+			if parent, ok := alias[*c.ParentID]; ok {
+				merged[i].ParentID = &parent
+			}
+		}
+	}
+
+	var mergedTotals []models.CategoryStats
+	index := make(map[uuid.UUID]int, len(totals))
+	for _, t := range totals {
+		if id, ok := alias[t.CategoryID]; ok {
+			t.CategoryID = id
+		}
+		if i, ok := index[t.CategoryID]; ok {
+			mergedTotals[i].Total += t.Total
+			mergedTotals[i].Count += t.Count
+			continue
+		}
+		index[t.CategoryID] = len(mergedTotals)
+		mergedTotals = append(mergedTotals, t)
+	}
+	return merged, mergedTotals
+}
+
+// buildCategoryTree arranges per-category totals into the category hierarchy
+// and rolls each node's totals up into its ancestors. Branches without any
+// expenses in the period are left out.
+func buildCategoryTree(categories []models.Category, totals []models.CategoryStats) []models.CategoryStats {
+	own := make(map[uuid.UUID]models.CategoryStats, len(totals))
+	for _, t := range totals {
+		own[t.CategoryID] = t
+	}
+
+	known := make(map[uuid.UUID]bool, len(categories))
+	for _, c := range categories {
+		known[c.ID] = true
+	}
+	parents := make(map[uuid.UUID]uuid.UUID, len(categories))
+	for _, c := range categories {
+		if c.ParentID != nil && known[*c.ParentID] {
+			parents[c.ID] = *c.ParentID
+		}
+	}
+
+	// Categories in a parent cycle, which folding a shared ledger's categories
+	// can produce, would never be reached from the top level; they are shown
+	// there instead.
+	children := make(map[uuid.UUID][]models.Category)
+	for _, c := range categories {
+		parent, ok := parents[c.ID]
+		if !ok || inParentCycle(parents, c.ID) {
+			parent = uuid.Nil
+		}
+		children[parent] = append(children[parent], c)
+	}
+
+	var build func(parent uuid.UUID) []models.CategoryStats
+	build = func(parent uuid.UUID) []models.CategoryStats {
+		var nodes []models.CategoryStats
+		for _, c := range children[parent] {
+			node := models.CategoryStats{
+				CategoryID: c.ID,
+				Category:   c.Name,
+				Total:      own[c.ID].Total,
+				Count:      own[c.ID].Count,
+				Children:   build(c.ID),
+			}
+			node.RolledUpTotal = node.Total
+			node.RolledUpCount = node.Count
+			for _, child := range node.Children {
+				node.RolledUpTotal += child.RolledUpTotal
+				node.RolledUpCount += child.RolledUpCount
+			}
+			if node.RolledUpCount > 0 {
+				nodes = append(nodes, node)
+			}
+		}
+		return nodes
+	}
+	tree := build(uuid.Nil)
+
+	// Expenses whose category is no longer known still count at the top level.
+	for _, t := range totals {
+		if !known[t.CategoryID] {
+			t.RolledUpTotal = t.Total
+			t.RolledUpCount = t.Count
+			tree = append(tree, t)
+		}
+	}
+
+	sortCategoryStats(tree)
+	return tree
+}
+
+// inParentCycle reports whether id is its own ancestor.
+func inParentCycle(parents map[uuid.UUID]uuid.UUID, id uuid.UUID) bool {
+	next := id
+	for range parents {
+		parent, ok := parents[next]
+		if !ok {
+			return false
+		}
+		if parent == id {
+			return true
+		}
+		next = parent
+	}
+	return false
+}
+
+func sortCategoryStats(nodes []models.CategoryStats) {
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].RolledUpTotal > nodes[j].RolledUpTotal
+	})
+	for i := range nodes {
+		sortCategoryStats(nodes[i].Children)
+	}
+}
+
+// resolveCategory finds one of the user's categories for the transaction
+// type by ID, or by name when no ID is given.
+func resolveCategory(categoryRepo repository.CategoryRepository, userID uuid.UUID, txType string, id *uuid.UUID, name string) (*models.Category, error) {
+	var category *models.Category
+	var err error
+	if id != nil {
+		category, err = categoryRepo.GetByID(*id, userID)
+	} else {
+		category, err = categoryRepo.GetByName(name, txType, userID)
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidCategory
+		}
+		return nil, err
+	}
+	if category.Type != txType {
+		return nil, ErrInvalidCategory
+	}
+	return category, nil
+}
+
+// resolveCurrency picks the currency of a new transaction: the requested
+// one, which must match the account if there is one, or else the account's
+// currency, or else the user's base currency.
+func resolveCurrency(accountRepo repository.AccountRepository, userRepo repository.UserRepository, userID uuid.UUID, accountID *uuid.UUID, currency string) (string, error) {
+	currency = strings.ToUpper(currency)
+	if accountID != nil {
+		account, err := getAccount(accountRepo, *accountID, userID)
+		if err != nil {
+			return "", err
+		}
+		if currency == "" {
+			return account.Currency, nil
+		}
+		if currency != account.Currency {
+			return "", ErrAccountCurrency
+		}
+	}
+	if currency != "" {
+		return currency, nil
+	}
+
+	user, err := userRepo.GetByID(userID)
+	if err != nil {
+		return "", err
+	}
+	return user.BaseCurrency, nil
+}
+
+// checkBudgets raises any budget alerts the expense triggered. Failing to do
+// so must not fail the expense itself, so errors are only logged.
+func checkBudgets(budgets BudgetService, expense *models.Expense) {
+	if expense.Type != models.TransactionTypeExpense {
+		return
+	}
+	if err := budgets.CheckAlerts(expense.UserID, expense.Date); err != nil {
+		log.Printf("Failed to check budgets for expense %s: %v", expense.ID, err)
+	}
+}
+
+func getAccount(accountRepo repository.AccountRepository, id, userID uuid.UUID) (*models.Account, error) {
+	account, err := accountRepo.GetByID(id, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidAccount
+		}
+		return nil, err
+	}
+	return account, nil
 }
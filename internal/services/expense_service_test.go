@@ -0,0 +1,235 @@
+package services
+
+import (
+	"testing"
+
+	"mamonedz/internal/models"
+	"mamonedz/pkg/money"
+
+	"github.com/google/uuid"
+)
+
+func category(id uuid.UUID, name string, parent *uuid.UUID, owner uuid.UUID) models.Category {
+	return models.Category{ID: id, UserID: owner, Name: name, ParentID: parent}
+}
+
+func stat(id uuid.UUID, total int64, count int) models.CategoryStats {
+	return models.CategoryStats{CategoryID: id, Total: money.FromMinor(total), Count: count}
+}
+
+// flatten indexes a tree by category ID and records each node's parent.
+func flatten(nodes []models.CategoryStats, parent uuid.UUID, into map[uuid.UUID]models.CategoryStats, parents map[uuid.UUID]uuid.UUID) {
+	for _, n := range nodes {
+		into[n.CategoryID] = n
+		parents[n.CategoryID] = parent
+		flatten(n.Children, n.CategoryID, into, parents)
+	}
+}
+
+func TestBuildCategoryTree(t *testing.T) {
+	user := uuid.New()
+	food, groceries, snacks, transport, gone := uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	cycleA, cycleB := uuid.New(), uuid.New()
+
+	type want struct {
+		parent        uuid.UUID
+		rolledUpTotal int64
+		rolledUpCount int
+	}
+	tests := []struct {
+		name       string
+		categories []models.Category
+		totals     []models.CategoryStats
+		want       map[uuid.UUID]want
+	}{
+		{
+			name: "rolls totals up into ancestors",
+			categories: []models.Category{
+				category(food, "Food", nil, user),
+				category(groceries, "Groceries", &food, user),
+				category(snacks, "Snacks", &groceries, user),
+			},
+			totals: []models.CategoryStats{stat(food, 1000, 1), stat(groceries, 2000, 2), stat(snacks, 500, 1)},
+			want: map[uuid.UUID]want{
+				food:      {uuid.Nil, 3500, 4},
+				groceries: {food, 2500, 3},
+				snacks:    {groceries, 500, 1},
+			},
+		},
+		{
+			name: "leaves out branches without expenses",
+			categories: []models.Category{
+				category(food, "Food", nil, user),
+				category(groceries, "Groceries", &food, user),
+				category(transport, "Transport", nil, user),
+			},
+			totals: []models.CategoryStats{stat(groceries, 2000, 2)},
+			want: map[uuid.UUID]want{
+				food:      {uuid.Nil, 2000, 2},
+				groceries: {food, 2000, 2},
+			},
+		},
+		{
+			name: "keeps totals of unknown categories at the top",
+			categories: []models.Category{
+				category(food, "Food", nil, user),
+			},
+			totals: []models.CategoryStats{stat(food, 100, 1), stat(gone, 700, 3)},
+			want: map[uuid.UUID]want{
+				food: {uuid.Nil, 100, 1},
+				gone: {uuid.Nil, 700, 3},
+			},
+		},
+		{
+			name: "moves children of unknown parents to the top",
+			categories: []models.Category{
+				category(groceries, "Groceries", &gone, user),
+			},
+			totals: []models.CategoryStats{stat(groceries, 100, 1)},
+			want: map[uuid.UUID]want{
+				groceries: {uuid.Nil, 100, 1},
+			},
+		},
+		{
+			name: "shows categories in a parent cycle at the top",
+			categories: []models.Category{
+				category(cycleA, "A", &cycleB, user),
+				category(cycleB, "B", &cycleA, user),
+				category(snacks, "Snacks", &cycleA, user),
+			},
+			totals: []models.CategoryStats{stat(cycleA, 100, 1), stat(cycleB, 200, 1), stat(snacks, 50, 1)},
+			want: map[uuid.UUID]want{
+				cycleA: {uuid.Nil, 150, 2},
+				cycleB: {uuid.Nil, 200, 1},
+				snacks: {cycleA, 50, 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree := buildCategoryTree(tt.categories, tt.totals)
+
+			nodes := make(map[uuid.UUID]models.CategoryStats)
+			parents := make(map[uuid.UUID]uuid.UUID)
+			flatten(tree, uuid.Nil, nodes, parents)
+			if len(nodes) != len(tt.want) {
+				t.Fatalf("tree has %d nodes, want %d", len(nodes), len(tt.want))
+			}
+			for id, w := range tt.want {
+				node, ok := nodes[id]
+				if !ok {
+					t.Fatalf("category %s missing from the tree", id)
+				}
+				if parents[id] != w.parent {
+					t.Errorf("%s: parent = %s, want %s", node.Category, parents[id], w.parent)
+				}
+				if node.RolledUpTotal != money.FromMinor(w.rolledUpTotal) || node.RolledUpCount != w.rolledUpCount {
+					t.Errorf("%s: rolled up %s/%d, want %s/%d", node.Category,
+						node.RolledUpTotal, node.RolledUpCount, money.FromMinor(w.rolledUpTotal), w.rolledUpCount)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildCategoryTreeSortsByRolledUpTotal(t *testing.T) {
+	user := uuid.New()
+	small, large := uuid.New(), uuid.New()
+	tree := buildCategoryTree(
+		[]models.Category{category(small, "Small", nil, user), category(large, "Large", nil, user)},
+		[]models.CategoryStats{stat(small, 100, 1), stat(large, 900, 1)},
+	)
+	if len(tree) != 2 || tree[0].CategoryID != large {
+		t.Errorf("tree is not sorted by rolled up total: %+v", tree)
+	}
+}
+
+func TestMergeCategories(t *testing.T) {
+	me, member := uuid.New(), uuid.New()
+	myFood, myGroceries := uuid.New(), uuid.New()
+	theirFood, theirGroceries, theirFuel, theirTransport := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+	categories := []models.Category{
+		category(theirFood, "Food", nil, member),
+		category(theirGroceries, "Groceries", &theirFood, member),
+		category(theirTransport, "Transport", nil, member),
+		category(theirFuel, "Fuel", &theirFood, member),
+		category(myFood, "Food", nil, me),
+		category(myGroceries, "Groceries", &myFood, me),
+	}
+	totals := []models.CategoryStats{
+		stat(myGroceries, 1000, 1),
+		stat(theirGroceries, 500, 2),
+		stat(theirFood, 300, 1),
+		stat(theirFuel, 200, 1),
+	}
+
+	merged, mergedTotals := mergeCategories(categories, totals, me)
+
+	byName := make(map[string]models.Category)
+	for _, c := range merged {
+		if _, dup := byName[c.Name]; dup {
+			t.Fatalf("%s appears twice after merging", c.Name)
+		}
+		byName[c.Name] = c
+	}
+	if len(byName) != 4 {
+		t.Fatalf("merged into %d categories, want 4", len(byName))
+	}
+	if byName["Food"].ID != myFood || byName["Groceries"].ID != myGroceries {
+		t.Error("the user's own categories were not the ones kept")
+	}
+	if fuel := byName["Fuel"]; fuel.ParentID == nil || *fuel.ParentID != myFood {
+		t.Error("Fuel was not moved under the kept Food category")
+	}
+
+	got := make(map[uuid.UUID]models.CategoryStats)
+	for _, s := range mergedTotals {
+		if _, dup := got[s.CategoryID]; dup {
+			t.Fatalf("category %s has two totals", s.CategoryID)
+		}
+		got[s.CategoryID] = s
+	}
+	want := map[uuid.UUID]models.CategoryStats{
+		myGroceries: stat(myGroceries, 1500, 3),
+		myFood:      stat(myFood, 300, 1),
+		theirFuel:   stat(theirFuel, 200, 1),
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d totals, want %d", len(got), len(want))
+	}
+	for id, w := range want {
+		if got[id].Total != w.Total || got[id].Count != w.Count {
+			t.Errorf("total of %s = %s/%d, want %s/%d", id, got[id].Total, got[id].Count, w.Total, w.Count)
+		}
+	}
+}
+
+func TestMergeCategoriesCycleStillCounts(t *testing.T) {
+	me, first, second := uuid.New(), uuid.New(), uuid.New()
+	firstA, firstB := uuid.New(), uuid.New()
+	secondA, secondB := uuid.New(), uuid.New()
+
+	// The first member keeps A and files it under B, the second keeps B
+	// and files it under A: after folding, A and B are each other's parent.
+	categories := []models.Category{
+		category(firstA, "A", &firstB, first),
+		category(secondB, "B", &secondA, second),
+		category(firstB, "B", nil, first),
+		category(secondA, "A", nil, second),
+	}
+	totals := []models.CategoryStats{stat(firstA, 100, 1), stat(secondB, 200, 1)}
+
+	tree := buildCategoryTree(mergeCategories(categories, totals, me))
+
+	var total int64
+	var count int
+	for _, n := range tree {
+		total += n.RolledUpTotal.Minor()
+		count += n.RolledUpCount
+	}
+	if total != 300 || count != 2 {
+		t.Errorf("top level adds up to %d/%d, want 300/2", total, count)
+	}
+}
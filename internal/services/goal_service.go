@@ -0,0 +1,404 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"time"
+
+	"mamonedz/internal/models"
+	"mamonedz/internal/repository"
+	"mamonedz/pkg/money"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// projectionWindow is how many months, up to the current one, the average
+	// monthly contribution is taken over.
+	projectionWindow = 6
+	// maxProjectionMonths caps the schedule of a projection.
+	maxProjectionMonths = 120
+)
+
+var (
+	ErrGoalNotFound         = errors.New("goal not found")
+	ErrContributionNotFound = errors.New("contribution not found")
+	ErrInvalidTransfer      = errors.New("invalid transfer")
+	ErrTransferLinked       = errors.New("transfer already recorded as a contribution")
+	ErrContributionExceeds  = errors.New("contribution is larger than its transfer")
+)
+
+type GoalService interface {
+	Create(userID uuid.UUID, req *models.CreateGoalRequest) (*models.GoalWithProgress, error)
+	GetAll(userID uuid.UUID) ([]models.GoalWithProgress, error)
+	GetByID(id, userID uuid.UUID) (*models.GoalWithProgress, error)
+	Update(id, userID uuid.UUID, req *models.UpdateGoalRequest) (*models.GoalWithProgress, error)
+	Delete(id, userID uuid.UUID) error
+	AddContribution(goalID, userID uuid.UUID, req *models.CreateContributionRequest) (*models.GoalContribution, error)
+	GetContributions(goalID, userID uuid.UUID, limit, offset int) ([]models.GoalContribution, int64, error)
+	DeleteContribution(id, goalID, userID uuid.UUID) error
+	GetProjection(id, userID uuid.UUID, monthly *money.Amount) (*models.GoalProjection, error)
+}
+
+type goalService struct {
+	repo         repository.GoalRepository
+	transferRepo repository.TransferRepository
+	accountRepo  repository.AccountRepository
+	userRepo     repository.UserRepository
+}
+
+func NewGoalService(repo repository.GoalRepository, transferRepo repository.TransferRepository, accountRepo repository.AccountRepository, userRepo repository.UserRepository) GoalService {
+	return &goalService{repo: repo, transferRepo: transferRepo, accountRepo: accountRepo, userRepo: userRepo}
+}
+
+func (s *goalService) Create(userID uuid.UUID, req *models.CreateGoalRequest) (*models.GoalWithProgress, error) {
+	currency, err := resolveCurrency(s.accountRepo, s.userRepo, userID, req.AccountID, req.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	goal := &models.SavingsGoal{
+		UserID:       userID,
+		Name:         strings.TrimSpace(req.Name),
+		TargetAmount: req.TargetAmount,
+		Currency:     currency,
+		AccountID:    req.AccountID,
+		Note:         req.Note,
+	}
+	if req.TargetDate != nil {
+		targetDate, err := time.Parse("2006-01-02", *req.TargetDate)
+		if err != nil {
+			return nil, ErrInvalidDate
+		}
+		goal.TargetDate = &targetDate
+	}
+
+	if err := s.repo.Create(goal); err != nil {
+		return nil, err
+	}
+	return withProgress(goal, 0, time.Now()), nil
+}
+
+func (s *goalService) GetAll(userID uuid.UUID) ([]models.GoalWithProgress, error) {
+	goals, err := s.repo.GetAllByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	saved, err := s.repo.GetSaved(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	result := make([]models.GoalWithProgress, len(goals))
+	for i := range goals {
+		result[i] = *withProgress(&goals[i], saved[goals[i].ID], now)
+	}
+	return result, nil
+}
+
+func (s *goalService) GetByID(id, userID uuid.UUID) (*models.GoalWithProgress, error) {
+	goal, err := s.goal(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	saved, err := s.repo.GetSaved(userID)
+	if err != nil {
+		return nil, err
+	}
+	return withProgress(goal, saved[goal.ID], time.Now()), nil
+}
+
+func (s *goalService) Update(id, userID uuid.UUID, req *models.UpdateGoalRequest) (*models.GoalWithProgress, error) {
+	goal, err := s.goal(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		goal.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.TargetAmount != nil {
+		goal.TargetAmount = *req.TargetAmount
+	}
+	if req.TargetDate != nil {
+		targetDate, err := time.Parse("2006-01-02", *req.TargetDate)
+		if err != nil {
+			return nil, ErrInvalidDate
+		}
+		goal.TargetDate = &targetDate
+	}
+	if req.ClearTargetDate {
+		goal.TargetDate = nil
+	}
+	if req.AccountID != nil {
+		account, err := getAccount(s.accountRepo, *req.AccountID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if account.Currency != goal.Currency {
+			return nil, ErrAccountCurrency
+		}
+		goal.AccountID = req.AccountID
+	}
+	if req.RemoveAccount {
+		goal.AccountID = nil
+	}
+	if req.Note != nil {
+		goal.Note = req.Note
+	}
+
+	goal.UpdatedAt = time.Now()
+
+	if err := s.repo.Update(goal); err != nil {
+		return nil, err
+	}
+
+	saved, err := s.repo.GetSaved(userID)
+	if err != nil {
+		return nil, err
+	}
+	return withProgress(goal, saved[goal.ID], time.Now()), nil
+}
+
+func (s *goalService) Delete(id, userID uuid.UUID) error {
+	err := s.repo.Delete(id, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrGoalNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// AddContribution records a contribution to the goal. A linked transfer has
+// to move money into an account in the goal's currency, and into the goal's
+// own account if it has one. Each transfer counts towards one contribution
+// at most.
+func (s *goalService) AddContribution(goalID, userID uuid.UUID, req *models.CreateContributionRequest) (*models.GoalContribution, error) {
+	goal, err := s.goal(goalID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	contribution := &models.GoalContribution{
+		UserID: userID,
+		GoalID: goal.ID,
+		Amount: req.Amount,
+		Note:   req.Note,
+	}
+
+	if req.TransferID != nil {
+		transfer, err := s.transfer(*req.TransferID, userID, goal)
+		if err != nil {
+			return nil, err
+		}
+		contribution.TransferID = &transfer.ID
+		contribution.Date = transfer.Date
+		if contribution.Amount == 0 {
+			contribution.Amount = transfer.Amount
+		}
+		if contribution.Amount > transfer.Amount {
+			return nil, ErrContributionExceeds
+		}
+		if contribution.Note == nil {
+			contribution.Note = transfer.Note
+		}
+	}
+	if req.Date != "" {
+		date, err := time.Parse("2006-01-02", req.Date)
+		if err != nil {
+			return nil, ErrInvalidDate
+		}
+		contribution.Date = date
+	}
+
+	created, err := s.repo.CreateContribution(contribution)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return nil, ErrTransferLinked
+	}
+	return contribution, nil
+}
+
+func (s *goalService) GetContributions(goalID, userID uuid.UUID, limit, offset int) ([]models.GoalContribution, int64, error) {
+	if _, err := s.goal(goalID, userID); err != nil {
+		return nil, 0, err
+	}
+	return s.repo.GetContributions(goalID, userID, limit, offset)
+}
+
+func (s *goalService) DeleteContribution(id, goalID, userID uuid.UUID) error {
+	err := s.repo.DeleteContribution(id, goalID, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrContributionNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// GetProjection projects the goal from monthly, or when it is nil from the
+// average monthly contribution over the last projectionWindow months, or
+// since the first contribution if that is more recent.
+func (s *goalService) GetProjection(id, userID uuid.UUID, monthly *money.Amount) (*models.GoalProjection, error) {
+	goal, err := s.goal(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	contributions, err := s.repo.GetMonthlyContributions(goal.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	current := monthOf(now)
+
+	var saved money.Amount
+	first := current
+	for key, amount := range contributions {
+		saved += amount
+		if month, err := ParseMonth(key); err == nil && month.Before(first) {
+			first = month
+		}
+	}
+	progress := withProgress(goal, saved, now)
+
+	projection := &models.GoalProjection{
+		GoalID:        goal.ID,
+		Saved:         saved,
+		Remaining:     progress.Remaining,
+		Basis:         models.ProjectionBasisRequested,
+		TargetDate:    goal.TargetDate,
+		MonthlyNeeded: progress.MonthlyNeeded,
+		Schedule:      []models.GoalProjectionMonth{},
+	}
+
+	if monthly != nil {
+		projection.MonthlyContribution = *monthly
+	} else {
+		projection.Basis = models.ProjectionBasisAverage
+		from := current.AddDate(0, 1-projectionWindow, 0)
+		if first.After(from) {
+			from = first
+		}
+		var total money.Amount
+		for m := from; !m.After(current); m = m.AddDate(0, 1, 0) {
+			total += contributions[m.Format("2006-01")]
+		}
+		if average := money.FromMinor(total.Minor() / int64(monthsBetween(from, current)+1)); average > 0 {
+			projection.MonthlyContribution = average
+		}
+	}
+
+	var reached *time.Time
+	switch {
+	case progress.Remaining <= 0:
+		reached = &current
+	case projection.MonthlyContribution > 0:
+		months := ceilDiv(progress.Remaining.Minor(), projection.MonthlyContribution.Minor())
+		month := current.AddDate(0, int(months), 0)
+		reached = &month
+
+		balance := saved
+		for i := 1; int64(i) <= months && i <= maxProjectionMonths; i++ {
+			balance += projection.MonthlyContribution
+			projection.Schedule = append(projection.Schedule, models.GoalProjectionMonth{
+				Month:        current.AddDate(0, i, 0).Format("2006-01"),
+				Contribution: projection.MonthlyContribution,
+				Saved:        balance,
+			})
+		}
+	}
+
+	if reached != nil {
+		value := reached.Format("2006-01")
+		projection.ProjectedMonth = &value
+	}
+	if goal.TargetDate != nil {
+		onTrack := reached != nil && !reached.After(monthOf(*goal.TargetDate))
+		projection.OnTrack = &onTrack
+	}
+	return projection, nil
+}
+
+func (s *goalService) goal(id, userID uuid.UUID) (*models.SavingsGoal, error) {
+	goal, err := s.repo.GetByID(id, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrGoalNotFound
+		}
+		return nil, err
+	}
+	return goal, nil
+}
+
+// transfer loads a transfer the user wants to record as a contribution to
+// goal.
+func (s *goalService) transfer(id, userID uuid.UUID, goal *models.SavingsGoal) (*models.Transfer, error) {
+	transfer, err := s.transferRepo.GetByID(id, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidTransfer
+		}
+		return nil, err
+	}
+	if goal.AccountID != nil && transfer.ToAccountID != *goal.AccountID {
+		return nil, ErrInvalidTransfer
+	}
+
+	account, err := getAccount(s.accountRepo, transfer.ToAccountID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if account.Currency != goal.Currency {
+		return nil, ErrAccountCurrency
+	}
+
+	return transfer, nil
+}
+
+// withProgress computes the goal's progress as of now from the amount saved.
+func withProgress(goal *models.SavingsGoal, saved money.Amount, now time.Time) *models.GoalWithProgress {
+	progress := &models.GoalWithProgress{
+		SavingsGoal: *goal,
+		Saved:       saved,
+		Remaining:   goal.TargetAmount - saved,
+	}
+	if progress.Remaining < 0 {
+		progress.Remaining = 0
+	}
+	if goal.TargetAmount > 0 {
+		progress.ProgressPercent = math.Round(saved.Float64()/goal.TargetAmount.Float64()*10000) / 100
+	}
+
+	if goal.TargetDate != nil {
+		monthsLeft := monthsBetween(monthOf(now), monthOf(*goal.TargetDate))
+		if monthsLeft < 0 {
+			monthsLeft = 0
+		}
+		needed := progress.Remaining
+		if monthsLeft > 0 {
+			needed = money.FromMinor(ceilDiv(needed.Minor(), int64(monthsLeft)))
+		}
+		progress.MonthsLeft = &monthsLeft
+		progress.MonthlyNeeded = &needed
+	}
+	return progress
+}
+
+// monthsBetween counts the calendar months from from's month to to's.
+func monthsBetween(from, to time.Time) int {
+	return (to.Year()-from.Year())*12 + int(to.Month()-from.Month())
+}
+
+// ceilDiv divides a non-negative a by a positive b, rounding up.
+func ceilDiv(a, b int64) int64 {
+	return (a + b - 1) / b
+}
@@ -0,0 +1,325 @@
+package services
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"sync"
+	"time"
+
+	"mamonedz/internal/models"
+	"mamonedz/internal/repository"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+
+	// keyringRefreshInterval bounds how long a key created by another
+	// instance can go unnoticed.
+	keyringRefreshInterval = time.Minute
+	// keyringPrepublish is how long a new key is published before it signs,
+	// so that other instances have loaded it by the time its tokens arrive.
+	keyringPrepublish = keyringRefreshInterval
+	// keyringMissReloadInterval throttles reloads triggered by tokens with an
+	// unknown kid, so garbage tokens cannot hammer the database.
+	keyringMissReloadInterval = 10 * time.Second
+	rsaKeyBits                = 2048
+)
+
+var ErrUnsupportedAlgorithm = errors.New("unsupported signing algorithm")
+
+type signingKey struct {
+	kid       string
+	algorithm string
+	method    jwt.SigningMethod
+	private   crypto.Signer
+	public    crypto.PublicKey
+	createdAt time.Time
+}
+
+// keyring holds the asymmetric keys used to sign and verify access tokens.
+// The newest key of the configured algorithm that has been published for
+// keyringPrepublish signs. Its successor is generated keyringPrepublish before
+// the signing key reaches the rotation interval. Older keys keep verifying for
+// the retention period, which must cover the lifetime of any token they
+// signed, and are then dropped.
+type keyring struct {
+	repo      repository.SigningKeyRepository
+	algorithm string
+	rotation  time.Duration
+	retention time.Duration
+
+	mu       sync.RWMutex
+	keys     []*signingKey
+	loadedAt time.Time
+	missAt   time.Time
+}
+
+func newKeyring(repo repository.SigningKeyRepository, algorithm string, rotation, retention time.Duration) *keyring {
+	return &keyring{
+		repo:      repo,
+		algorithm: algorithm,
+		rotation:  rotation,
+		retention: retention,
+	}
+}
+
+// Current returns the key to sign new tokens with, generating the next key
+// when due.
+func (k *keyring) Current() (*signingKey, error) {
+	if err := k.reloadIfStale(); err != nil {
+		return nil, err
+	}
+
+	k.mu.RLock()
+	key, due := k.currentLocked(time.Now())
+	k.mu.RUnlock()
+	if !due {
+		return key, nil
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	// Another goroutine may have rotated while we waited for the lock.
+	if key, due = k.currentLocked(time.Now()); !due {
+		return key, nil
+	}
+
+	next, err := k.generate()
+	if err != nil {
+		return nil, err
+	}
+	if err := k.repo.DeleteCreatedBefore(time.Now().Add(-k.rotation - k.retention)); err != nil {
+		log.Printf("Failed to delete retired signing keys: %v", err)
+	}
+	if err := k.load(); err != nil {
+		return nil, err
+	}
+	if key == nil {
+		// No token can be signed with anything else, so the new key signs
+		// right away.
+		return next, nil
+	}
+	return key, nil
+}
+
+// Lookup returns the verification key with the given kid.
+func (k *keyring) Lookup(kid string) (*signingKey, error) {
+	if err := k.reloadIfStale(); err != nil {
+		return nil, err
+	}
+	if key := k.find(kid); key != nil {
+		return key, nil
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if time.Since(k.missAt) < keyringMissReloadInterval {
+		return nil, ErrInvalidToken
+	}
+	k.missAt = time.Now()
+	if err := k.load(); err != nil {
+		return nil, err
+	}
+	if key := k.findLocked(kid); key != nil {
+		return key, nil
+	}
+	return nil, ErrInvalidToken
+}
+
+// JWKS returns the public half of every key that may still verify tokens.
+func (k *keyring) JWKS() (*models.JWKS, error) {
+	if err := k.reloadIfStale(); err != nil {
+		return nil, err
+	}
+
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	jwks := &models.JWKS{Keys: make([]models.JWK, 0, len(k.keys))}
+	for _, key := range k.keys {
+		jwk := models.JWK{Kid: key.kid, Use: "sig", Alg: key.algorithm}
+		switch pub := key.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		default:
+			continue
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	return jwks, nil
+}
+
+// currentLocked returns the key to sign with at now: the newest key of the
+// configured algorithm published for at least keyringPrepublish, or a newer
+// one when there is no other. due reports that the next key should be
+// generated. Callers must hold k.mu.
+func (k *keyring) currentLocked(now time.Time) (current *signingKey, due bool) {
+	var pending *signingKey
+	for _, key := range k.keys {
+		if key.algorithm != k.algorithm {
+			continue
+		}
+		if now.Sub(key.createdAt) < keyringPrepublish {
+			pending = key
+			continue
+		}
+		current = key
+		break
+	}
+
+	switch {
+	case current == nil:
+		return pending, pending == nil
+	case pending != nil:
+		return current, false
+	default:
+		return current, now.Sub(current.createdAt) >= k.rotation-keyringPrepublish
+	}
+}
+
+func (k *keyring) find(kid string) *signingKey {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.findLocked(kid)
+}
+
+// findLocked returns the key with the given kid. Callers must hold k.mu.
+func (k *keyring) findLocked(kid string) *signingKey {
+	for _, key := range k.keys {
+		if key.kid == kid {
+			return key
+		}
+	}
+	return nil
+}
+
+func (k *keyring) reloadIfStale() error {
+	k.mu.RLock()
+	stale := time.Since(k.loadedAt) >= keyringRefreshInterval
+	k.mu.RUnlock()
+	if !stale {
+		return nil
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if time.Since(k.loadedAt) < keyringRefreshInterval {
+		return nil
+	}
+	return k.load()
+}
+
+// load replaces the cached keys with those still inside the verification
+// window. Callers must hold k.mu for writing.
+func (k *keyring) load() error {
+	rows, err := k.repo.GetCreatedSince(time.Now().Add(-k.rotation - k.retention))
+	if err != nil {
+		return err
+	}
+
+	keys := make([]*signingKey, 0, len(rows))
+	for i := range rows {
+		key, err := parseSigningKey(&rows[i])
+		if err != nil {
+			log.Printf("Skipping signing key %s: %v", rows[i].KID, err)
+			continue
+		}
+		keys = append(keys, key)
+	}
+
+	k.keys = keys
+	k.loadedAt = time.Now()
+	return nil
+}
+
+// generate creates and stores a new key. Callers must hold k.mu for writing.
+func (k *keyring) generate() (*signingKey, error) {
+	var private crypto.Signer
+	switch k.algorithm {
+	case AlgorithmRS256:
+		key, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+		if err != nil {
+			return nil, err
+		}
+		private = key
+	case AlgorithmEdDSA:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		private = key
+	default:
+		return nil, ErrUnsupportedAlgorithm
+	}
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(private.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	row := &models.SigningKey{
+		KID:        uuid.NewString(),
+		Algorithm:  k.algorithm,
+		PrivateKey: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER})),
+		PublicKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})),
+		CreatedAt:  time.Now(),
+	}
+	if err := k.repo.Create(row); err != nil {
+		return nil, err
+	}
+	log.Printf("Generated new %s signing key %s", row.Algorithm, row.KID)
+
+	return parseSigningKey(row)
+}
+
+func parseSigningKey(row *models.SigningKey) (*signingKey, error) {
+	method := jwt.GetSigningMethod(row.Algorithm)
+	if method == nil || (row.Algorithm != AlgorithmRS256 && row.Algorithm != AlgorithmEdDSA) {
+		return nil, ErrUnsupportedAlgorithm
+	}
+
+	block, _ := pem.Decode([]byte(row.PrivateKey))
+	if block == nil {
+		return nil, fmt.Errorf("invalid private key PEM")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	private, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("private key is not a signer")
+	}
+
+	return &signingKey{
+		kid:       row.KID,
+		algorithm: row.Algorithm,
+		method:    method,
+		private:   private,
+		public:    private.Public(),
+		createdAt: row.CreatedAt,
+	}, nil
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestKeyringCurrent(t *testing.T) {
+	now := time.Now()
+	rotation := 30 * 24 * time.Hour
+	key := func(kid, algorithm string, age time.Duration) *signingKey {
+		return &signingKey{kid: kid, algorithm: algorithm, createdAt: now.Add(-age)}
+	}
+
+	tests := []struct {
+		name    string
+		keys    []*signingKey
+		current string
+		due     bool
+	}{
+		{
+			name: "no keys",
+			due:  true,
+		},
+		{
+			name:    "first key signs right away",
+			keys:    []*signingKey{key("first", AlgorithmRS256, time.Second)},
+			current: "first",
+		},
+		{
+			name:    "key within its rotation",
+			keys:    []*signingKey{key("a", AlgorithmRS256, 24*time.Hour)},
+			current: "a",
+		},
+		{
+			name:    "next key is generated before the rotation",
+			keys:    []*signingKey{key("a", AlgorithmRS256, rotation-keyringPrepublish/2)},
+			current: "a",
+			due:     true,
+		},
+		{
+			name: "next key is published but does not sign yet",
+			keys: []*signingKey{
+				key("b", AlgorithmRS256, keyringPrepublish/2),
+				key("a", AlgorithmRS256, rotation-keyringPrepublish/2),
+			},
+			current: "a",
+		},
+		{
+			name: "next key signs once published long enough",
+			keys: []*signingKey{
+				key("b", AlgorithmRS256, keyringPrepublish),
+				key("a", AlgorithmRS256, rotation),
+			},
+			current: "b",
+		},
+		{
+			name: "only the configured algorithm signs",
+			keys: []*signingKey{key("ed", AlgorithmEdDSA, time.Hour)},
+			due:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := &keyring{algorithm: AlgorithmRS256, rotation: rotation, keys: tt.keys}
+			current, due := k.currentLocked(now)
+
+			kid := ""
+			if current != nil {
+				kid = current.kid
+			}
+			if kid != tt.current || due != tt.due {
+				t.Errorf("currentLocked = %q, due %v; want %q, due %v", kid, due, tt.current, tt.due)
+			}
+		})
+	}
+}
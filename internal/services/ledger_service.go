@@ -0,0 +1,394 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"mamonedz/internal/mailer"
+	"mamonedz/internal/models"
+	"mamonedz/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrLedgerNotFound     = errors.New("ledger not found")
+	ErrLedgerForbidden    = errors.New("not allowed in this ledger")
+	ErrPersonalLedger     = errors.New("personal ledgers cannot be shared or deleted")
+	ErrLedgerInUse        = errors.New("ledger still has transactions")
+	ErrLedgerOwner        = errors.New("the ledger owner cannot leave or change role")
+	ErrAlreadyMember      = errors.New("user is already a member")
+	ErrMemberNotFound     = errors.New("member not found")
+	ErrInvitationNotFound = errors.New("invitation not found")
+	ErrInvalidPayer       = errors.New("payer must be a member of the ledger")
+	ErrInviteeUnverified  = errors.New("email must be verified to answer invitations")
+)
+
+type LedgerService interface {
+	Create(userID uuid.UUID, req *models.CreateLedgerRequest) (*models.LedgerWithRole, error)
+	GetAll(userID uuid.UUID) ([]models.LedgerWithRole, error)
+	GetByID(id, userID uuid.UUID) (*models.LedgerWithRole, error)
+	Update(id, userID uuid.UUID, req *models.UpdateLedgerRequest) (*models.LedgerWithRole, error)
+	Delete(id, userID uuid.UUID) error
+	GetMembers(id, userID uuid.UUID) ([]models.LedgerMemberInfo, error)
+	UpdateMember(id, userID, memberID uuid.UUID, req *models.UpdateMemberRequest) error
+	RemoveMember(id, userID, memberID uuid.UUID) error
+	Invite(id, userID uuid.UUID, req *models.InviteMemberRequest) (*models.LedgerInvitation, error)
+	GetInvitations(id, userID uuid.UUID) ([]models.LedgerInvitation, error)
+	RevokeInvitation(id, userID, invitationID uuid.UUID) error
+	GetMyInvitations(userID uuid.UUID) ([]models.PendingInvitation, error)
+	AcceptInvitation(invitationID, userID uuid.UUID) (*models.LedgerWithRole, error)
+	DeclineInvitation(invitationID, userID uuid.UUID) error
+}
+
+type ledgerService struct {
+	repo          repository.LedgerRepository
+	userRepo      repository.UserRepository
+	expenseRepo   repository.ExpenseRepository
+	mailer        mailer.Mailer
+	appURL        string
+	invitationTTL time.Duration
+}
+
+func NewLedgerService(repo repository.LedgerRepository, userRepo repository.UserRepository, expenseRepo repository.ExpenseRepository, mail mailer.Mailer, appURL string, invitationTTL time.Duration) LedgerService {
+	return &ledgerService{
+		repo:          repo,
+		userRepo:      userRepo,
+		expenseRepo:   expenseRepo,
+		mailer:        mail,
+		appURL:        appURL,
+		invitationTTL: invitationTTL,
+	}
+}
+
+func (s *ledgerService) Create(userID uuid.UUID, req *models.CreateLedgerRequest) (*models.LedgerWithRole, error) {
+	currency := strings.ToUpper(req.BaseCurrency)
+	if currency == "" {
+		user, err := s.userRepo.GetByID(userID)
+		if err != nil {
+			return nil, err
+		}
+		currency = user.BaseCurrency
+	}
+
+	ledger := &models.Ledger{
+		UserID:       userID,
+		Name:         strings.TrimSpace(req.Name),
+		BaseCurrency: currency,
+	}
+	if err := s.repo.Create(ledger); err != nil {
+		return nil, err
+	}
+	return &models.LedgerWithRole{Ledger: *ledger, Role: models.LedgerRoleOwner}, nil
+}
+
+func (s *ledgerService) GetAll(userID uuid.UUID) ([]models.LedgerWithRole, error) {
+	return s.repo.GetAllByUser(userID)
+}
+
+func (s *ledgerService) GetByID(id, userID uuid.UUID) (*models.LedgerWithRole, error) {
+	return getLedger(s.repo, id, userID)
+}
+
+func (s *ledgerService) Update(id, userID uuid.UUID, req *models.UpdateLedgerRequest) (*models.LedgerWithRole, error) {
+	ledger, err := s.owned(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		ledger.Name = strings.TrimSpace(*req.Name)
+	}
+	reconvert := false
+	if req.BaseCurrency != nil {
+		currency := strings.ToUpper(*req.BaseCurrency)
+		if currency != ledger.BaseCurrency {
+			if ledger.Personal {
+				return nil, ErrPersonalLedger
+			}
+			ledger.BaseCurrency = currency
+			reconvert = true
+		}
+	}
+
+	ledger.UpdatedAt = time.Now()
+
+	if err := s.repo.Update(&ledger.Ledger); err != nil {
+		return nil, err
+	}
+	if reconvert {
+		if err := s.expenseRepo.ConvertByLedger(ledger.ID); err != nil {
+			return nil, err
+		}
+	}
+	return ledger, nil
+}
+
+// Delete removes a shared ledger once it has no transactions left.
+func (s *ledgerService) Delete(id, userID uuid.UUID) error {
+	ledger, err := s.owned(id, userID)
+	if err != nil {
+		return err
+	}
+	if ledger.Personal {
+		return ErrPersonalLedger
+	}
+
+	count, err := s.repo.CountExpenses(id)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrLedgerInUse
+	}
+
+	if err := s.repo.Delete(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrLedgerNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *ledgerService) GetMembers(id, userID uuid.UUID) ([]models.LedgerMemberInfo, error) {
+	if _, err := getLedger(s.repo, id, userID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetMembers(id)
+}
+
+func (s *ledgerService) UpdateMember(id, userID, memberID uuid.UUID, req *models.UpdateMemberRequest) error {
+	ledger, err := s.owned(id, userID)
+	if err != nil {
+		return err
+	}
+	if memberID == ledger.UserID {
+		return ErrLedgerOwner
+	}
+
+	if err := s.repo.SetRole(id, memberID, req.Role); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrMemberNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// RemoveMember lets the owner remove any other member, and any member leave.
+// The transactions they recorded stay in the ledger.
+func (s *ledgerService) RemoveMember(id, userID, memberID uuid.UUID) error {
+	ledger, err := getLedger(s.repo, id, userID)
+	if err != nil {
+		return err
+	}
+	if memberID == ledger.UserID {
+		return ErrLedgerOwner
+	}
+	if memberID != userID && ledger.Role != models.LedgerRoleOwner {
+		return ErrLedgerForbidden
+	}
+
+	if err := s.repo.RemoveMember(id, memberID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrMemberNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// Invite invites an email address to the ledger and emails the invitation
+// there. The address does not need to belong to a user yet.
+func (s *ledgerService) Invite(id, userID uuid.UUID, req *models.InviteMemberRequest) (*models.LedgerInvitation, error) {
+	ledger, err := s.owned(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if ledger.Personal {
+		return nil, ErrPersonalLedger
+	}
+
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	invitee, err := s.userRepo.GetByEmailFold(email)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if invitee != nil {
+		if _, err := s.repo.GetMember(id, invitee.ID); err == nil {
+			return nil, ErrAlreadyMember
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+
+	invitation := &models.LedgerInvitation{
+		LedgerID:  id,
+		Email:     email,
+		Role:      req.Role,
+		InvitedBy: userID,
+		ExpiresAt: time.Now().Add(s.invitationTTL),
+		CreatedAt: time.Now(),
+	}
+	if err := s.repo.SaveInvitation(invitation); err != nil {
+		return nil, err
+	}
+
+	s.notify(invitation, &ledger.Ledger, userID)
+	return invitation, nil
+}
+
+func (s *ledgerService) GetInvitations(id, userID uuid.UUID) ([]models.LedgerInvitation, error) {
+	if _, err := s.owned(id, userID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetInvitations(id)
+}
+
+func (s *ledgerService) RevokeInvitation(id, userID, invitationID uuid.UUID) error {
+	if _, err := s.owned(id, userID); err != nil {
+		return err
+	}
+	invitation, err := s.repo.GetInvitation(invitationID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvitationNotFound
+		}
+		return err
+	}
+	if invitation.LedgerID != id {
+		return ErrInvitationNotFound
+	}
+	return s.repo.DeleteInvitation(invitation.ID)
+}
+
+func (s *ledgerService) GetMyInvitations(userID uuid.UUID) ([]models.PendingInvitation, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.EmailVerifiedAt == nil {
+		return nil, ErrInviteeUnverified
+	}
+	return s.repo.GetInvitationsByEmail(strings.ToLower(user.Email))
+}
+
+func (s *ledgerService) AcceptInvitation(invitationID, userID uuid.UUID) (*models.LedgerWithRole, error) {
+	invitation, err := s.invitation(invitationID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.AcceptInvitation(invitation, userID); err != nil {
+		return nil, err
+	}
+	return getLedger(s.repo, invitation.LedgerID, userID)
+}
+
+func (s *ledgerService) DeclineInvitation(invitationID, userID uuid.UUID) error {
+	invitation, err := s.invitation(invitationID, userID)
+	if err != nil {
+		return err
+	}
+	return s.repo.DeleteInvitation(invitation.ID)
+}
+
+// owned returns the ledger if the user owns it.
+func (s *ledgerService) owned(id, userID uuid.UUID) (*models.LedgerWithRole, error) {
+	ledger, err := getLedger(s.repo, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if ledger.Role != models.LedgerRoleOwner {
+		return nil, ErrLedgerForbidden
+	}
+	return ledger, nil
+}
+
+// invitation returns a pending invitation addressed to the user's email.
+// Invitations are matched by address only, so the address must be verified
+// whether or not REQUIRE_VERIFIED_EMAIL is set; otherwise anyone could sign
+// up with the invitee's address and take their place.
+func (s *ledgerService) invitation(id, userID uuid.UUID) (*models.LedgerInvitation, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.EmailVerifiedAt == nil {
+		return nil, ErrInviteeUnverified
+	}
+	invitation, err := s.repo.GetInvitation(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvitationNotFound
+		}
+		return nil, err
+	}
+	if invitation.Email != strings.ToLower(user.Email) {
+		return nil, ErrInvitationNotFound
+	}
+	return invitation, nil
+}
+
+func (s *ledgerService) notify(invitation *models.LedgerInvitation, ledger *models.Ledger, userID uuid.UUID) {
+	inviter, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		log.Printf("Failed to load user for ledger invitation %s: %v", invitation.ID, err)
+		return
+	}
+
+	err = s.mailer.Send(&mailer.Message{
+		To:      invitation.Email,
+		Subject: fmt.Sprintf("%s invited you to %s", inviter.Name, ledger.Name),
+		Body: fmt.Sprintf(
+			"Hi,\n\n%s invited you to join the ledger %s as %s. Sign in with this email address to accept the invitation before %s:\n\n%s/ledgers/invitations\n",
+			inviter.Name, ledger.Name, invitation.Role, invitation.ExpiresAt.Format("2 January 2006"), s.appURL,
+		),
+	})
+	if err != nil {
+		log.Printf("Failed to send ledger invitation %s: %v", invitation.ID, err)
+	}
+}
+
+// getLedger returns the ledger with the user's role in it, if they are a
+// member.
+func getLedger(ledgerRepo repository.LedgerRepository, id, userID uuid.UUID) (*models.LedgerWithRole, error) {
+	ledger, err := ledgerRepo.GetByID(id, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrLedgerNotFound
+		}
+		return nil, err
+	}
+	return ledger, nil
+}
+
+// resolveLedger picks the ledger a transaction is read from or written to:
+// the requested one, which the user must be a member of with a write role
+// when write is set, or else their personal ledger.
+func resolveLedger(ledgerRepo repository.LedgerRepository, userID uuid.UUID, id *uuid.UUID, write bool) (*models.Ledger, error) {
+	if id == nil {
+		return ledgerRepo.GetPersonal(userID)
+	}
+	ledger, err := getLedger(ledgerRepo, *id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if write && !canWrite(ledger.Role) {
+		return nil, ErrLedgerForbidden
+	}
+	return &ledger.Ledger, nil
+}
+
+func canWrite(role string) bool {
+	for _, r := range models.LedgerWriteRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
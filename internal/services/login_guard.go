@@ -0,0 +1,136 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"mamonedz/internal/config"
+	"mamonedz/internal/repository"
+)
+
+var (
+	ErrAccountLocked   = errors.New("account temporarily locked")
+	ErrTooManyAttempts = errors.New("too many login attempts")
+)
+
+// LoginThrottledError is returned when a login is refused before the
+// password is even checked. Locked distinguishes a locked account from a
+// rate-limited client so the handler can answer 423 or 429.
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+	Locked     bool
+}
+
+func (e *LoginThrottledError) Error() string {
+	return fmt.Sprintf("%v, retry after %s", e.Unwrap(), e.RetryAfter.Round(time.Second))
+}
+
+func (e *LoginThrottledError) Unwrap() error {
+	if e.Locked {
+		return ErrAccountLocked
+	}
+	return ErrTooManyAttempts
+}
+
+// loginGuard applies brute-force protection to password logins. Failures are
+// counted per account and per client IP; each failure adds an exponentially
+// growing delay before the next attempt, and reaching the limit locks the key
+// for the configured duration.
+type loginGuard struct {
+	store         repository.LoginAttemptStore
+	maxAttempts   int
+	ipMaxAttempts int
+	window        time.Duration
+	lockout       time.Duration
+	baseDelay     time.Duration
+}
+
+func newLoginGuard(store repository.LoginAttemptStore, cfg *config.Config) *loginGuard {
+	return &loginGuard{
+		store:         store,
+		maxAttempts:   cfg.LoginMaxAttempts,
+		ipMaxAttempts: cfg.LoginIPMaxAttempts,
+		window:        cfg.LoginAttemptWindow,
+		lockout:       cfg.LoginLockoutDuration,
+		baseDelay:     cfg.LoginDelayBase,
+	}
+}
+
+func (g *loginGuard) Check(email, ip string) error {
+	now := time.Now()
+	for _, key := range g.keys(email, ip) {
+		attempt, err := g.store.Get(key.name)
+		if err != nil {
+			return err
+		}
+		if attempt == nil {
+			continue
+		}
+
+		if attempt.LockedUntil != nil && now.Before(*attempt.LockedUntil) {
+			return &LoginThrottledError{RetryAfter: attempt.LockedUntil.Sub(now), Locked: key.account}
+		}
+		if now.Sub(attempt.FirstFailureAt) > g.window {
+			continue
+		}
+		if next := attempt.LastFailureAt.Add(g.delay(attempt.Failures)); now.Before(next) {
+			return &LoginThrottledError{RetryAfter: next.Sub(now)}
+		}
+	}
+	return nil
+}
+
+func (g *loginGuard) Fail(email, ip string) error {
+	for _, key := range g.keys(email, ip) {
+		attempt, err := g.store.RecordFailure(key.name, g.window)
+		if err != nil {
+			return err
+		}
+		if attempt.Failures >= key.max {
+			if err := g.store.Lock(key.name, time.Now().Add(g.lockout)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Succeed clears the account counter. The IP counter is left to expire on
+// its own so that one valid account cannot be used to reset it.
+func (g *loginGuard) Succeed(email string) error {
+	return g.store.Reset(accountKey(email))
+}
+
+func (g *loginGuard) delay(failures int) time.Duration {
+	if failures <= 0 || g.baseDelay <= 0 {
+		return 0
+	}
+	d := g.baseDelay
+	for i := 1; i < failures && d < g.lockout; i++ {
+		d *= 2
+	}
+	if d > g.lockout {
+		d = g.lockout
+	}
+	return d
+}
+
+type guardKey struct {
+	name    string
+	max     int
+	account bool
+}
+
+func (g *loginGuard) keys(email, ip string) []guardKey {
+	keys := []guardKey{{name: accountKey(email), max: g.maxAttempts, account: true}}
+	if ip != "" {
+		keys = append(keys, guardKey{name: "ip:" + ip, max: g.ipMaxAttempts})
+	}
+	return keys
+}
+
+func accountKey(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
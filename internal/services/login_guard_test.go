@@ -0,0 +1,141 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"mamonedz/internal/repository"
+)
+
+func newTestLoginGuard(baseDelay time.Duration) *loginGuard {
+	return &loginGuard{
+		store:         repository.NewMemoryLoginAttemptStore(),
+		maxAttempts:   3,
+		ipMaxAttempts: 5,
+		window:        time.Hour,
+		lockout:       time.Hour,
+		baseDelay:     baseDelay,
+	}
+}
+
+func TestLoginGuardDelay(t *testing.T) {
+	tests := []struct {
+		name      string
+		baseDelay time.Duration
+		lockout   time.Duration
+		failures  int
+		want      time.Duration
+	}{
+		{"no failures", time.Second, time.Hour, 0, 0},
+		{"first failure", time.Second, time.Hour, 1, time.Second},
+		{"doubles per failure", time.Second, time.Hour, 4, 8 * time.Second},
+		{"capped at the lockout", time.Second, 10 * time.Second, 10, 10 * time.Second},
+		{"disabled", 0, time.Hour, 5, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &loginGuard{baseDelay: tt.baseDelay, lockout: tt.lockout}
+			if got := g.delay(tt.failures); got != tt.want {
+				t.Errorf("delay(%d) = %s, want %s", tt.failures, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoginGuardThrottlesAfterFailure(t *testing.T) {
+	g := newTestLoginGuard(time.Minute)
+
+	if err := g.Check("user@example.com", "10.0.0.1"); err != nil {
+		t.Fatalf("first attempt refused: %v", err)
+	}
+	if err := g.Fail("user@example.com", "10.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+
+	err := g.Check("user@example.com", "10.0.0.2")
+	var throttled *LoginThrottledError
+	if !errors.As(err, &throttled) {
+		t.Fatalf("Check = %v, want a throttling error", err)
+	}
+	if throttled.Locked || !errors.Is(err, ErrTooManyAttempts) {
+		t.Errorf("got %v, want a delay rather than a lockout", err)
+	}
+	if throttled.RetryAfter <= 0 || throttled.RetryAfter > time.Minute {
+		t.Errorf("RetryAfter = %s, want at most the base delay", throttled.RetryAfter)
+	}
+}
+
+func TestLoginGuardLocksAccount(t *testing.T) {
+	g := newTestLoginGuard(0)
+
+	for i := 0; i < g.maxAttempts; i++ {
+		if err := g.Check("User@Example.com ", ""); err != nil {
+			t.Fatalf("attempt %d refused before the limit: %v", i+1, err)
+		}
+		if err := g.Fail("User@Example.com ", ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := g.Check("user@example.com", "")
+	var throttled *LoginThrottledError
+	if !errors.As(err, &throttled) || !throttled.Locked || !errors.Is(err, ErrAccountLocked) {
+		t.Fatalf("Check = %v, want the account locked", err)
+	}
+	if throttled.RetryAfter <= 0 || throttled.RetryAfter > g.lockout {
+		t.Errorf("RetryAfter = %s, want within the lockout", throttled.RetryAfter)
+	}
+
+	if err := g.Check("other@example.com", ""); err != nil {
+		t.Errorf("lockout spilled over to another account: %v", err)
+	}
+}
+
+func TestLoginGuardLocksIP(t *testing.T) {
+	g := newTestLoginGuard(0)
+
+	for i := 0; i < g.ipMaxAttempts; i++ {
+		if err := g.Fail(fmt.Sprintf("user%d@example.com", i), "10.0.0.1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := g.Check("fresh@example.com", "10.0.0.1")
+	var throttled *LoginThrottledError
+	if !errors.As(err, &throttled) || throttled.Locked {
+		t.Fatalf("Check = %v, want the client rate-limited", err)
+	}
+	if err := g.Check("fresh@example.com", "10.0.0.2"); err != nil {
+		t.Errorf("lockout spilled over to another IP: %v", err)
+	}
+}
+
+func TestLoginGuardSucceedResetsAccountOnly(t *testing.T) {
+	g := newTestLoginGuard(0)
+
+	for i := 0; i < g.maxAttempts-1; i++ {
+		if err := g.Fail("user@example.com", "10.0.0.1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := g.Succeed("user@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Fail("user@example.com", "10.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Check("user@example.com", ""); err != nil {
+		t.Errorf("account still counted failures from before the success: %v", err)
+	}
+
+	ip, err := g.store.Get("ip:10.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ip == nil || ip.Failures != g.maxAttempts {
+		t.Errorf("IP counter = %+v, want %d failures", ip, g.maxAttempts)
+	}
+}
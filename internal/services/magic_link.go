@@ -0,0 +1,111 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"mamonedz/internal/config"
+	"mamonedz/internal/mailer"
+	"mamonedz/internal/models"
+	"mamonedz/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// magicLinkLimiter caps how many login links can be requested for one email
+// address per window. It shares the login attempt store, so the limit holds
+// across replicas when the Postgres store is used.
+type magicLinkLimiter struct {
+	store       repository.LoginAttemptStore
+	maxRequests int
+	window      time.Duration
+}
+
+func newMagicLinkLimiter(store repository.LoginAttemptStore, cfg *config.Config) *magicLinkLimiter {
+	return &magicLinkLimiter{
+		store:       store,
+		maxRequests: cfg.MagicLinkMaxRequests,
+		window:      cfg.MagicLinkWindow,
+	}
+}
+
+// Allow counts a request for email and returns a LoginThrottledError once the
+// limit for the current window is exceeded.
+func (l *magicLinkLimiter) Allow(email string) error {
+	attempt, err := l.store.RecordFailure("magic:"+strings.ToLower(email), l.window)
+	if err != nil {
+		return err
+	}
+	if attempt.Failures > l.maxRequests {
+		return &LoginThrottledError{RetryAfter: time.Until(attempt.FirstFailureAt.Add(l.window))}
+	}
+	return nil
+}
+
+// SendMagicLink emails a single-use login link. Requests for unknown emails
+// succeed silently so the endpoint cannot be used to probe for accounts, but
+// they still count towards the rate limit.
+func (s *authService) SendMagicLink(req *models.MagicLinkRequest) error {
+	if err := s.magicLinkLimiter.Allow(req.Email); err != nil {
+		return err
+	}
+
+	user, err := s.userRepo.GetByEmail(req.Email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	if err := s.userTokenRepo.InvalidateForUser(user.ID, models.TokenPurposeMagicLink); err != nil {
+		return err
+	}
+
+	raw, err := s.createUserToken(user.ID, models.TokenPurposeMagicLink, "", s.magicLinkTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(&mailer.Message{
+		To:      user.Email,
+		Subject: "Your Mamonedz login link",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nOpen the link below to log in. It can be used once and expires in %s.\n\n%s/magic-link?token=%s\n\nIf you did not request this, you can ignore this email.\n",
+			user.Name, s.magicLinkTTL, s.appURL, raw,
+		),
+	})
+}
+
+// LoginWithMagicLink consumes a login link and logs the user in exactly as a
+// password login would, including the MFA challenge. Opening the link proves
+// ownership of the address, so it also verifies the email.
+func (s *authService) LoginWithMagicLink(req *models.MagicLinkLoginRequest, client *models.ClientInfo) (*models.AuthResponse, error) {
+	token, err := s.userTokenRepo.Consume(hashToken(req.Token), models.TokenPurposeMagicLink)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidMagicLink
+		}
+		return nil, err
+	}
+
+	user, err := s.GetUserByID(token.UserID)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return nil, ErrInvalidMagicLink
+		}
+		return nil, err
+	}
+
+	if user.EmailVerifiedAt == nil {
+		if err := s.userRepo.MarkEmailVerified(user.ID); err != nil {
+			return nil, err
+		}
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+
+	return s.CompleteLogin(user, client)
+}
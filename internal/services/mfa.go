@@ -0,0 +1,221 @@
+package services
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	"mamonedz/internal/models"
+	"mamonedz/pkg/totp"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrMFAAlreadyEnabled = errors.New("two-factor authentication already enabled")
+	ErrMFANotEnabled     = errors.New("two-factor authentication not enabled")
+	ErrMFASetupRequired  = errors.New("two-factor authentication setup not started")
+	ErrInvalidMFACode    = errors.New("invalid two-factor code")
+)
+
+const (
+	recoveryCodeCount  = 10
+	recoveryCodeLength = 10
+	// totpSkew accepts codes from one step before and after the current one
+	// to tolerate clock drift on the user's device.
+	totpSkew = 1
+)
+
+// SetupMFA generates a new pending TOTP secret. Two-factor authentication is
+// not active until EnableMFA confirms a first code.
+func (s *authService) SetupMFA(userID uuid.UUID) (*models.MFASetupResponse, error) {
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabledAt != nil {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.SetTOTPSecret(user.ID, secret); err != nil {
+		return nil, err
+	}
+
+	return &models.MFASetupResponse{
+		Secret:     secret,
+		OTPAuthURI: totp.URI(s.totpIssuer, user.Email, secret),
+	}, nil
+}
+
+// EnableMFA confirms the pending secret with a code from the authenticator
+// app and returns a fresh set of recovery codes. The codes are shown once.
+func (s *authService) EnableMFA(userID uuid.UUID, req *models.MFAEnableRequest) (*models.MFAEnableResponse, error) {
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabledAt != nil {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if user.TOTPSecret == nil {
+		return nil, ErrMFASetupRequired
+	}
+
+	step, ok := totp.Validate(*user.TOTPSecret, req.Code, time.Now(), totpSkew)
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	codes, rows, err := generateRecoveryCodes(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.recoveryCodeRepo.ReplaceForUser(user.ID, rows); err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.EnableTOTP(user.ID, step); err != nil {
+		return nil, err
+	}
+
+	return &models.MFAEnableResponse{RecoveryCodes: codes}, nil
+}
+
+func (s *authService) DisableMFA(userID uuid.UUID, req *models.MFADisableRequest) error {
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if user.TOTPEnabledAt == nil {
+		return ErrMFANotEnabled
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		return ErrInvalidCredentials
+	}
+	if err := s.checkMFACode(user, req.Code); err != nil {
+		return err
+	}
+
+	if err := s.recoveryCodeRepo.DeleteForUser(user.ID); err != nil {
+		return err
+	}
+	return s.userRepo.DisableTOTP(user.ID)
+}
+
+// VerifyMFA completes a login that was answered with an MFA challenge.
+// Failed codes count towards the same lockout as failed passwords.
+func (s *authService) VerifyMFA(req *models.MFAVerifyRequest, client *models.ClientInfo) (*models.AuthResponse, error) {
+	_, userID, err := s.parseToken(req.MFAToken, tokenTypeMFA)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabledAt == nil {
+		return nil, ErrInvalidToken
+	}
+	if err := s.loginGuard.Check(user.Email, client.IP); err != nil {
+		return nil, err
+	}
+	if err := s.checkMFACode(user, req.Code); err != nil {
+		if errors.Is(err, ErrInvalidMFACode) {
+			if err := s.loginGuard.Fail(user.Email, client.IP); err != nil {
+				return nil, err
+			}
+		}
+		return nil, err
+	}
+	if err := s.loginGuard.Succeed(user.Email); err != nil {
+		return nil, err
+	}
+
+	return s.startSession(user, client)
+}
+
+func (s *authService) newMFAChallenge(user *models.User) (*models.AuthResponse, error) {
+	token, err := s.signToken(jwt.MapClaims{
+		"typ":     tokenTypeMFA,
+		"user_id": user.ID.String(),
+		"exp":     time.Now().Add(s.mfaChallengeTTL).Unix(),
+		"iat":     time.Now().Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AuthResponse{
+		User:        user.ToResponse(),
+		MFARequired: true,
+		MFAToken:    token,
+	}, nil
+}
+
+// checkMFACode accepts either a TOTP code that has not been used before or an
+// unused recovery code.
+func (s *authService) checkMFACode(user *models.User, code string) error {
+	code = strings.TrimSpace(code)
+
+	if user.TOTPSecret != nil {
+		if step, ok := totp.Validate(*user.TOTPSecret, code, time.Now(), totpSkew); ok {
+			advanced, err := s.userRepo.AdvanceTOTPStep(user.ID, step)
+			if err != nil {
+				return err
+			}
+			if !advanced {
+				return ErrInvalidMFACode
+			}
+			return nil
+		}
+	}
+
+	used, err := s.recoveryCodeRepo.Consume(user.ID, hashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+// recoveryAlphabet leaves out characters that are easy to confuse when read
+// back from paper (0/O, 1/I/L).
+const recoveryAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+
+func generateRecoveryCodes(userID uuid.UUID) ([]string, []models.RecoveryCode, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	rows := make([]models.RecoveryCode, 0, recoveryCodeCount)
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, recoveryCodeLength)
+		for j := range b {
+			n, err := rand.Int(rand.Reader, big.NewInt(int64(len(recoveryAlphabet))))
+			if err != nil {
+				return nil, nil, err
+			}
+			b[j] = recoveryAlphabet[n.Int64()]
+		}
+		code := string(b[:recoveryCodeLength/2]) + "-" + string(b[recoveryCodeLength/2:])
+		codes = append(codes, code)
+		rows = append(rows, models.RecoveryCode{
+			UserID:   userID,
+			CodeHash: hashToken(normalizeRecoveryCode(code)),
+		})
+	}
+
+	return codes, rows, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
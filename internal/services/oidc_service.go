@@ -0,0 +1,204 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"mamonedz/internal/config"
+	"mamonedz/internal/models"
+	"mamonedz/internal/oidc"
+	"mamonedz/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUnknownProvider   = errors.New("unknown identity provider")
+	ErrInvalidOIDCState  = errors.New("invalid or expired login state")
+	ErrOIDCLoginFailed   = errors.New("identity provider login failed")
+	ErrOIDCEmailRequired = errors.New("identity provider did not return a verified email")
+	ErrOIDCEmailTaken    = errors.New("an unverified account already uses this email")
+)
+
+// oidcStateTTL is how long the user has to complete the provider's login page.
+const oidcStateTTL = 10 * time.Minute
+
+type OIDCService interface {
+	Providers() []string
+	AuthorizationURL(ctx context.Context, provider string) (*models.OIDCAuthorizationResponse, error)
+	Callback(ctx context.Context, provider string, req *models.OIDCCallbackRequest, client *models.ClientInfo) (*models.AuthResponse, error)
+}
+
+type oidcService struct {
+	providers    map[string]*oidc.Provider
+	names        []string
+	identityRepo repository.IdentityRepository
+	userRepo     repository.UserRepository
+	authService  AuthService
+}
+
+func NewOIDCService(
+	identityRepo repository.IdentityRepository,
+	userRepo repository.UserRepository,
+	authService AuthService,
+	cfg *config.Config,
+) OIDCService {
+	s := &oidcService{
+		providers:    make(map[string]*oidc.Provider),
+		identityRepo: identityRepo,
+		userRepo:     userRepo,
+		authService:  authService,
+	}
+	for _, p := range cfg.OIDCProviders {
+		s.providers[p.Name] = oidc.NewProvider(oidc.Config{
+			Name:         p.Name,
+			Issuer:       p.Issuer,
+			ClientID:     p.ClientID,
+			ClientSecret: p.ClientSecret,
+			RedirectURL:  p.RedirectURL,
+			Scopes:       p.Scopes,
+		})
+		s.names = append(s.names, p.Name)
+	}
+	return s
+}
+
+func (s *oidcService) Providers() []string {
+	return s.names
+}
+
+// AuthorizationURL starts a login: it stores a fresh state, nonce and PKCE
+// verifier and returns the provider URL to send the user to.
+func (s *oidcService) AuthorizationURL(ctx context.Context, provider string) (*models.OIDCAuthorizationResponse, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+
+	state, _, err := generateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	nonce, _, err := generateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	verifier, _, err := generateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.identityRepo.CreateState(&models.OIDCState{
+		State:        hashToken(state),
+		Provider:     provider,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    time.Now().Add(oidcStateTTL),
+	}); err != nil {
+		return nil, err
+	}
+
+	url, err := p.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		return nil, err
+	}
+	return &models.OIDCAuthorizationResponse{AuthorizationURL: url}, nil
+}
+
+// Callback completes the login. The identity is matched by provider subject
+// first; otherwise a verified email links it to an existing user, and as a
+// last resort a new user is created.
+func (s *oidcService) Callback(ctx context.Context, provider string, req *models.OIDCCallbackRequest, client *models.ClientInfo) (*models.AuthResponse, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+
+	state, err := s.identityRepo.ConsumeState(hashToken(req.State), provider)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidOIDCState
+		}
+		return nil, err
+	}
+
+	claims, err := p.Exchange(ctx, req.Code, state.CodeVerifier, state.Nonce)
+	if err != nil {
+		if errors.Is(err, oidc.ErrInvalidIDToken) || errors.Is(err, oidc.ErrExchangeFailed) {
+			return nil, ErrOIDCLoginFailed
+		}
+		return nil, err
+	}
+
+	user, err := s.resolveUser(provider, claims)
+	if err != nil {
+		return nil, err
+	}
+	return s.authService.CompleteLogin(user, client)
+}
+
+func (s *oidcService) resolveUser(provider string, claims *oidc.Claims) (*models.User, error) {
+	identity, err := s.identityRepo.GetByProviderSubject(provider, claims.Subject)
+	if err == nil {
+		return s.authService.GetUserByID(identity.UserID)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	// Never link or create an account from an address the provider has not
+	// verified; anyone could otherwise take over an existing account.
+	if claims.Email == "" || !claims.EmailVerified {
+		return nil, ErrOIDCEmailRequired
+	}
+
+	// Only link to an account whose owner has proven the address. Anyone can
+	// register an unverified account for someone else's email and keep its
+	// password, so linking to one would hand them the victim's account.
+	user, err := s.userRepo.GetByEmailFold(claims.Email)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		if user, err = s.createUser(claims); err != nil {
+			return nil, err
+		}
+	} else if user.EmailVerifiedAt == nil {
+		return nil, ErrOIDCEmailTaken
+	}
+
+	if err := s.identityRepo.Create(&models.ExternalIdentity{
+		UserID:   user.ID,
+		Provider: provider,
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+	}); err != nil {
+		return nil, err
+	}
+	return s.authService.GetUserByID(user.ID)
+}
+
+// createUser registers a user without a password. They can set one later
+// through the forgot-password flow.
+func (s *oidcService) createUser(claims *oidc.Claims) (*models.User, error) {
+	name := strings.TrimSpace(claims.Name)
+	if name == "" {
+		name = strings.SplitN(claims.Email, "@", 2)[0]
+	}
+	if len(name) > 100 {
+		name = name[:100]
+	}
+
+	now := time.Now()
+	user := &models.User{
+		Name:            name,
+		Email:           claims.Email,
+		EmailVerifiedAt: &now,
+	}
+	if err := s.userRepo.Create(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
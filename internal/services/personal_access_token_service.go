@@ -0,0 +1,125 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"mamonedz/internal/models"
+	"mamonedz/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAccessTokenNotFound = errors.New("personal access token not found")
+	ErrInvalidScope        = errors.New("invalid scope")
+)
+
+// lastUsedInterval limits how often last_used_at is written for a token that
+// is used on every request.
+const lastUsedInterval = time.Minute
+
+type PersonalAccessTokenService interface {
+	Create(userID uuid.UUID, req *models.CreatePersonalAccessTokenRequest) (*models.CreatePersonalAccessTokenResponse, error)
+	GetAll(userID uuid.UUID) ([]models.PersonalAccessToken, error)
+	Revoke(id, userID uuid.UUID) error
+	Authenticate(raw string) (*models.PersonalAccessToken, error)
+}
+
+type personalAccessTokenService struct {
+	repo repository.PersonalAccessTokenRepository
+}
+
+func NewPersonalAccessTokenService(repo repository.PersonalAccessTokenRepository) PersonalAccessTokenService {
+	return &personalAccessTokenService{repo: repo}
+}
+
+func (s *personalAccessTokenService) Create(userID uuid.UUID, req *models.CreatePersonalAccessTokenRequest) (*models.CreatePersonalAccessTokenResponse, error) {
+	for _, scope := range req.Scopes {
+		if !models.ValidScopes[scope] {
+			return nil, ErrInvalidScope
+		}
+	}
+
+	random, _, err := generateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	raw := models.PersonalAccessTokenPrefix + random
+
+	token := &models.PersonalAccessToken{
+		UserID:      userID,
+		Name:        req.Name,
+		TokenHash:   hashToken(raw),
+		TokenPrefix: raw[:len(models.PersonalAccessTokenPrefix)+4],
+		Scopes:      uniqueScopes(req.Scopes),
+	}
+	if req.ExpiresInDays != nil {
+		expiresAt := time.Now().AddDate(0, 0, *req.ExpiresInDays)
+		token.ExpiresAt = &expiresAt
+	}
+
+	if err := s.repo.Create(token); err != nil {
+		return nil, err
+	}
+
+	return &models.CreatePersonalAccessTokenResponse{
+		PersonalAccessToken: token,
+		Token:               raw,
+	}, nil
+}
+
+func (s *personalAccessTokenService) GetAll(userID uuid.UUID) ([]models.PersonalAccessToken, error) {
+	return s.repo.GetAllByUser(userID)
+}
+
+func (s *personalAccessTokenService) Revoke(id, userID uuid.UUID) error {
+	if err := s.repo.Revoke(id, userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrAccessTokenNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// Authenticate resolves a raw token to its active record and records its use.
+func (s *personalAccessTokenService) Authenticate(raw string) (*models.PersonalAccessToken, error) {
+	token, err := s.repo.GetByHash(hashToken(raw))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidToken
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if token.ExpiresAt != nil && now.After(*token.ExpiresAt) {
+		return nil, ErrInvalidToken
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) > lastUsedInterval {
+		if err := s.repo.TouchLastUsed(token.ID, now); err != nil {
+			log.Printf("Failed to update last use of token %s: %v", token.ID, err)
+		}
+		token.LastUsedAt = &now
+	}
+
+	return token, nil
+}
+
+func uniqueScopes(scopes []string) []string {
+	seen := make(map[string]bool, len(scopes))
+	result := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if !seen[scope] {
+			seen[scope] = true
+			result = append(result, scope)
+		}
+	}
+	return result
+}
@@ -0,0 +1,32 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// StartRecurringScheduler materializes due recurring expenses every interval
+// until ctx is done. The first run happens right away, so occurrences that
+// fell due while the server was down are created at startup.
+func StartRecurringScheduler(ctx context.Context, service RecurringService, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			created, err := service.MaterializeDue(time.Now())
+			if err != nil {
+				log.Printf("Failed to materialize recurring expenses: %v", err)
+			} else if created > 0 {
+				log.Printf("Created %d recurring expense(s)", created)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
@@ -0,0 +1,372 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"mamonedz/internal/models"
+	"mamonedz/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// dueBatchSize caps how many templates one scheduler run processes; the rest
+// wait for the next run.
+const dueBatchSize = 100
+
+var (
+	ErrRecurringNotFound = errors.New("recurring expense not found")
+	ErrRecurringFinished = errors.New("recurring expense has no further occurrences")
+	ErrRecurringConflict = errors.New("recurring expense changed concurrently")
+	ErrInvalidSchedule   = errors.New("end date is before start date")
+)
+
+type RecurringService interface {
+	Create(userID uuid.UUID, req *models.CreateRecurringExpenseRequest) (*models.RecurringExpense, error)
+	GetAll(userID uuid.UUID) ([]models.RecurringExpense, error)
+	GetByID(id, userID uuid.UUID) (*models.RecurringExpense, error)
+	Update(id, userID uuid.UUID, req *models.UpdateRecurringExpenseRequest) (*models.RecurringExpense, error)
+	Delete(id, userID uuid.UUID) error
+	Skip(id, userID uuid.UUID) (*models.RecurringExpense, error)
+	Pause(id, userID uuid.UUID) (*models.RecurringExpense, error)
+	Resume(id, userID uuid.UUID) (*models.RecurringExpense, error)
+	MaterializeDue(now time.Time) (int, error)
+}
+
+type recurringService struct {
+	repo         repository.RecurringExpenseRepository
+	categoryRepo repository.CategoryRepository
+	accountRepo  repository.AccountRepository
+	userRepo     repository.UserRepository
+	ledgerRepo   repository.LedgerRepository
+	budgets      BudgetService
+}
+
+func NewRecurringService(repo repository.RecurringExpenseRepository, categoryRepo repository.CategoryRepository, accountRepo repository.AccountRepository, userRepo repository.UserRepository, ledgerRepo repository.LedgerRepository, budgets BudgetService) RecurringService {
+	return &recurringService{repo: repo, categoryRepo: categoryRepo, accountRepo: accountRepo, userRepo: userRepo, ledgerRepo: ledgerRepo, budgets: budgets}
+}
+
+func (s *recurringService) Create(userID uuid.UUID, req *models.CreateRecurringExpenseRequest) (*models.RecurringExpense, error) {
+	txType := req.Type
+	if txType == "" {
+		txType = models.TransactionTypeExpense
+	}
+
+	category, err := resolveCategory(s.categoryRepo, userID, txType, &req.CategoryID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	currency, err := resolveCurrency(s.accountRepo, s.userRepo, userID, req.AccountID, req.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	startDate, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		return nil, ErrInvalidDate
+	}
+
+	interval := req.Interval
+	if interval == 0 {
+		interval = 1
+	}
+
+	template := &models.RecurringExpense{
+		UserID:         userID,
+		Type:           txType,
+		Amount:         req.Amount,
+		Currency:       currency,
+		CategoryID:     category.ID,
+		AccountID:      req.AccountID,
+		Note:           req.Note,
+		Frequency:      req.Frequency,
+		Interval:       interval,
+		StartDate:      startDate,
+		MaxOccurrences: req.MaxOccurrences,
+	}
+	if req.EndDate != nil {
+		endDate, err := time.Parse("2006-01-02", *req.EndDate)
+		if err != nil {
+			return nil, ErrInvalidDate
+		}
+		if endDate.Before(startDate) {
+			return nil, ErrInvalidSchedule
+		}
+		template.EndDate = &endDate
+	}
+	template.NextDate = nextOccurrence(template)
+
+	if err := s.repo.Create(template); err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+func (s *recurringService) GetAll(userID uuid.UUID) ([]models.RecurringExpense, error) {
+	return s.repo.GetAllByUser(userID)
+}
+
+func (s *recurringService) GetByID(id, userID uuid.UUID) (*models.RecurringExpense, error) {
+	template, err := s.repo.GetByID(id, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecurringNotFound
+		}
+		return nil, err
+	}
+	return template, nil
+}
+
+func (s *recurringService) Update(id, userID uuid.UUID, req *models.UpdateRecurringExpenseRequest) (*models.RecurringExpense, error) {
+	template, err := s.GetByID(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Amount != nil {
+		template.Amount = *req.Amount
+	}
+	if req.CategoryID != nil {
+		category, err := resolveCategory(s.categoryRepo, userID, template.Type, req.CategoryID, "")
+		if err != nil {
+			return nil, err
+		}
+		template.CategoryID = category.ID
+	}
+	if req.AccountID != nil {
+		account, err := getAccount(s.accountRepo, *req.AccountID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if account.Currency != template.Currency {
+			return nil, ErrAccountCurrency
+		}
+		template.AccountID = req.AccountID
+	}
+	if req.RemoveAccount {
+		template.AccountID = nil
+	}
+	if req.Note != nil {
+		template.Note = req.Note
+	}
+
+	if req.EndDate != nil || req.MaxOccurrences != nil || req.ClearEnd {
+		template.EndDate = nil
+		template.MaxOccurrences = req.MaxOccurrences
+		if req.EndDate != nil {
+			endDate, err := time.Parse("2006-01-02", *req.EndDate)
+			if err != nil {
+				return nil, ErrInvalidDate
+			}
+			if endDate.Before(template.StartDate) {
+				return nil, ErrInvalidSchedule
+			}
+			template.EndDate = &endDate
+		}
+		template.NextDate = nextOccurrence(template)
+	}
+
+	template.UpdatedAt = time.Now()
+
+	if err := s.repo.Update(template, template.Occurrences); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecurringConflict
+		}
+		return nil, err
+	}
+	return template, nil
+}
+
+func (s *recurringService) Delete(id, userID uuid.UUID) error {
+	if err := s.repo.Delete(id, userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrRecurringNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// Skip moves past the next occurrence without creating an expense for it.
+func (s *recurringService) Skip(id, userID uuid.UUID) (*models.RecurringExpense, error) {
+	template, err := s.GetByID(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if template.NextDate == nil {
+		return nil, ErrRecurringFinished
+	}
+
+	previous := template.Occurrences
+	template.Occurrences++
+	template.NextDate = nextOccurrence(template)
+
+	if _, err := s.repo.Advance(template, previous, nil); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecurringConflict
+		}
+		return nil, err
+	}
+	return template, nil
+}
+
+func (s *recurringService) Pause(id, userID uuid.UUID) (*models.RecurringExpense, error) {
+	now := time.Now()
+	if err := s.repo.SetPaused(id, userID, &now); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecurringNotFound
+		}
+		return nil, err
+	}
+	return s.GetByID(id, userID)
+}
+
+// Resume restarts a paused template. Occurrences that fell due while it was
+// paused are skipped rather than created after the fact.
+func (s *recurringService) Resume(id, userID uuid.UUID) (*models.RecurringExpense, error) {
+	template, err := s.GetByID(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if template.PausedAt == nil {
+		return template, nil
+	}
+
+	previous := template.Occurrences
+	today := dateOf(time.Now())
+	for template.NextDate != nil && template.NextDate.Before(today) {
+		template.Occurrences++
+		template.NextDate = nextOccurrence(template)
+	}
+
+	if err := s.repo.Resume(template, previous); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecurringConflict
+		}
+		return nil, err
+	}
+	template.PausedAt = nil
+	return template, nil
+}
+
+// MaterializeDue creates the expenses of every occurrence due on or before
+// now, catching up on any that were missed, and returns how many it created.
+// It is safe to run concurrently from several processes: each occurrence is
+// claimed by advancing its template, which only one of them can do.
+func (s *recurringService) MaterializeDue(now time.Time) (int, error) {
+	today := dateOf(now)
+	due, err := s.repo.GetDue(today, dueBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	created := 0
+	for i := range due {
+		n, err := s.materialize(&due[i], today)
+		created += n
+		if err != nil {
+			log.Printf("Failed to materialize recurring expense %s: %v", due[i].ID, err)
+		}
+	}
+	return created, nil
+}
+
+func (s *recurringService) materialize(template *models.RecurringExpense, today time.Time) (int, error) {
+	category, err := s.categoryRepo.GetByID(template.CategoryID, template.UserID)
+	if err != nil {
+		return 0, err
+	}
+	ledger, err := s.ledgerRepo.GetPersonal(template.UserID)
+	if err != nil {
+		return 0, err
+	}
+
+	created := 0
+	for template.NextDate != nil && !template.NextDate.After(today) {
+		expense := &models.Expense{
+			UserID:      template.UserID,
+			LedgerID:    ledger.ID,
+			PaidBy:      template.UserID,
+			Type:        template.Type,
+			Amount:      template.Amount,
+			Currency:    template.Currency,
+			CategoryID:  category.ID,
+			Category:    category.Name,
+			AccountID:   template.AccountID,
+			RecurringID: &template.ID,
+			Date:        *template.NextDate,
+			Note:        template.Note,
+		}
+
+		previous := template.Occurrences
+		template.Occurrences++
+		template.NextDate = nextOccurrence(template)
+
+		inserted, err := s.repo.Advance(template, previous, expense)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				// Another scheduler is working on this template.
+				return created, nil
+			}
+			return created, err
+		}
+		if !inserted {
+			// The occurrence already had its expense.
+			continue
+		}
+		checkBudgets(s.budgets, expense)
+		created++
+	}
+	return created, nil
+}
+
+// nextOccurrence returns the date of the template's next occurrence after
+// the ones already counted, or nil when the template has ended.
+func nextOccurrence(template *models.RecurringExpense) *time.Time {
+	if template.MaxOccurrences != nil && template.Occurrences >= *template.MaxOccurrences {
+		return nil
+	}
+	date := occurrenceDate(template, template.Occurrences)
+	if template.EndDate != nil && date.After(*template.EndDate) {
+		return nil
+	}
+	return &date
+}
+
+// occurrenceDate returns the date of the nth occurrence, counting from zero.
+// Each date is computed from the start date, so a monthly template starting
+// on the 31st falls on the 30th in April and on the 31st again in May.
+func occurrenceDate(template *models.RecurringExpense, n int) time.Time {
+	steps := n * template.Interval
+	switch template.Frequency {
+	case models.FrequencyWeekly:
+		return template.StartDate.AddDate(0, 0, 7*steps)
+	case models.FrequencyMonthly:
+		return addMonths(template.StartDate, steps)
+	case models.FrequencyYearly:
+		return addMonths(template.StartDate, 12*steps)
+	default:
+		return template.StartDate.AddDate(0, 0, steps)
+	}
+}
+
+// addMonths adds months to t, moving the day back to the end of the month
+// where the month is too short instead of overflowing into the next one.
+func addMonths(t time.Time, months int) time.Time {
+	year, month, day := t.Date()
+	first := time.Date(year, month+time.Month(months), 1, 0, 0, 0, 0, t.Location())
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return time.Date(first.Year(), first.Month(), day, 0, 0, 0, 0, t.Location())
+}
+
+// dateOf returns the calendar date of t in the server's time zone, as
+// midnight UTC like dates read from the database.
+func dateOf(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
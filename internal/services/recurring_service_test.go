@@ -0,0 +1,172 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"mamonedz/internal/models"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestAddMonths(t *testing.T) {
+	tests := []struct {
+		start  time.Time
+		months int
+		want   time.Time
+	}{
+		{date(2024, time.January, 15), 1, date(2024, time.February, 15)},
+		{date(2024, time.January, 31), 1, date(2024, time.February, 29)},
+		{date(2023, time.January, 31), 1, date(2023, time.February, 28)},
+		{date(2024, time.January, 31), 3, date(2024, time.April, 30)},
+		{date(2024, time.January, 31), 4, date(2024, time.May, 31)},
+		{date(2024, time.November, 30), 3, date(2025, time.February, 28)},
+		{date(2024, time.December, 31), 1, date(2025, time.January, 31)},
+		{date(2024, time.February, 29), 12, date(2025, time.February, 28)},
+		{date(2024, time.February, 29), 48, date(2028, time.February, 29)},
+		{date(2024, time.March, 31), -1, date(2024, time.February, 29)},
+	}
+
+	for _, tt := range tests {
+		if got := addMonths(tt.start, tt.months); !got.Equal(tt.want) {
+			t.Errorf("addMonths(%s, %d) = %s, want %s",
+				tt.start.Format(time.DateOnly), tt.months, got.Format(time.DateOnly), tt.want.Format(time.DateOnly))
+		}
+	}
+}
+
+func TestOccurrenceDate(t *testing.T) {
+	tests := []struct {
+		name      string
+		frequency string
+		interval  int
+		start     time.Time
+		want      []time.Time
+	}{
+		{
+			name:      "monthly from the 31st keeps the start day",
+			frequency: models.FrequencyMonthly,
+			interval:  1,
+			start:     date(2024, time.January, 31),
+			want: []time.Time{
+				date(2024, time.January, 31),
+				date(2024, time.February, 29),
+				date(2024, time.March, 31),
+				date(2024, time.April, 30),
+				date(2024, time.May, 31),
+			},
+		},
+		{
+			name:      "every other month from the 31st",
+			frequency: models.FrequencyMonthly,
+			interval:  2,
+			start:     date(2023, time.December, 31),
+			want: []time.Time{
+				date(2023, time.December, 31),
+				date(2024, time.February, 29),
+				date(2024, time.April, 30),
+				date(2024, time.June, 30),
+				date(2024, time.August, 31),
+			},
+		},
+		{
+			name:      "yearly from Feb 29",
+			frequency: models.FrequencyYearly,
+			interval:  1,
+			start:     date(2024, time.February, 29),
+			want: []time.Time{
+				date(2024, time.February, 29),
+				date(2025, time.February, 28),
+				date(2026, time.February, 28),
+				date(2027, time.February, 28),
+				date(2028, time.February, 29),
+			},
+		},
+		{
+			name:      "weekly",
+			frequency: models.FrequencyWeekly,
+			interval:  2,
+			start:     date(2024, time.February, 26),
+			want: []time.Time{
+				date(2024, time.February, 26),
+				date(2024, time.March, 11),
+				date(2024, time.March, 25),
+			},
+		},
+		{
+			name:      "daily across Feb 29",
+			frequency: models.FrequencyDaily,
+			interval:  1,
+			start:     date(2024, time.February, 28),
+			want: []time.Time{
+				date(2024, time.February, 28),
+				date(2024, time.February, 29),
+				date(2024, time.March, 1),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template := &models.RecurringExpense{Frequency: tt.frequency, Interval: tt.interval, StartDate: tt.start}
+			for n, want := range tt.want {
+				if got := occurrenceDate(template, n); !got.Equal(want) {
+					t.Errorf("occurrence %d = %s, want %s", n, got.Format(time.DateOnly), want.Format(time.DateOnly))
+				}
+			}
+		})
+	}
+}
+
+func TestNextOccurrence(t *testing.T) {
+	end := date(2024, time.March, 31)
+	limit := 2
+
+	tests := []struct {
+		name     string
+		template models.RecurringExpense
+		want     *time.Time
+	}{
+		{
+			name:     "within the end date",
+			template: models.RecurringExpense{Occurrences: 2, EndDate: &end},
+			want:     ptr(date(2024, time.March, 31)),
+		},
+		{
+			name:     "past the end date",
+			template: models.RecurringExpense{Occurrences: 3, EndDate: &end},
+		},
+		{
+			name:     "below the maximum",
+			template: models.RecurringExpense{Occurrences: 1, MaxOccurrences: &limit},
+			want:     ptr(date(2024, time.February, 29)),
+		},
+		{
+			name:     "maximum reached",
+			template: models.RecurringExpense{Occurrences: 2, MaxOccurrences: &limit},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.template.Frequency = models.FrequencyMonthly
+			tt.template.Interval = 1
+			tt.template.StartDate = date(2024, time.January, 31)
+
+			got := nextOccurrence(&tt.template)
+			switch {
+			case got == nil && tt.want == nil:
+			case got == nil || tt.want == nil:
+				t.Errorf("nextOccurrence = %v, want %v", got, tt.want)
+			case !got.Equal(*tt.want):
+				t.Errorf("nextOccurrence = %s, want %s", got.Format(time.DateOnly), tt.want.Format(time.DateOnly))
+			}
+		})
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"mamonedz/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// sessionTouches remembers when each session's last-seen time was last
+// written so that busy clients cause at most one write per interval.
+type sessionTouches struct {
+	interval time.Duration
+
+	mu   sync.Mutex
+	last map[uuid.UUID]time.Time
+}
+
+func newSessionTouches(interval time.Duration) *sessionTouches {
+	return &sessionTouches{interval: interval, last: make(map[uuid.UUID]time.Time)}
+}
+
+// due reports whether the session should be written now and, if so, records
+// the write.
+func (t *sessionTouches) due(id uuid.UUID, now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if last, ok := t.last[id]; ok && now.Sub(last) < t.interval {
+		return false
+	}
+	for sid, last := range t.last {
+		if now.Sub(last) >= t.interval {
+			delete(t.last, sid)
+		}
+	}
+	t.last[id] = now
+	return true
+}
+
+func (t *sessionTouches) forget(id uuid.UUID) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.last, id)
+}
+
+// TouchSession updates the session's last-seen time, at most once per
+// configured interval. It fails when the session has been revoked, which is
+// how revocations made on other instances reach this one without a lookup
+// on every request.
+func (s *authService) TouchSession(claims *TokenClaims, client *models.ClientInfo) error {
+	if claims.SessionID == uuid.Nil {
+		return nil
+	}
+
+	now := time.Now()
+	if !s.sessionTouches.due(claims.SessionID, now) {
+		return nil
+	}
+
+	active, err := s.sessionRepo.Touch(claims.SessionID, client.IP, now)
+	if err != nil {
+		return err
+	}
+	if !active {
+		s.denylist.Add(sessionDenylistKey(claims.SessionID), now.Add(s.accessTokenTTL))
+		return ErrInvalidToken
+	}
+	return nil
+}
+
+func (s *authService) GetSessions(userID uuid.UUID, current uuid.UUID) ([]models.SessionResponse, error) {
+	sessions, err := s.sessionRepo.GetActiveByUser(userID, time.Now().Add(-s.refreshTokenTTL))
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]models.SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		result = append(result, models.SessionResponse{
+			Session: session,
+			Current: session.ID == current,
+		})
+	}
+	return result, nil
+}
+
+// RevokeSession signs out one device: the session, its refresh tokens and
+// every access token issued for it.
+func (s *authService) RevokeSession(id, userID uuid.UUID) error {
+	if err := s.sessionRepo.Revoke(id, userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSessionNotFound
+		}
+		return err
+	}
+	if err := s.refreshTokenRepo.RevokeFamily(id); err != nil {
+		return err
+	}
+
+	// Access tokens of this session stay valid for at most accessTokenTTL, so
+	// the denylist entry can expire after that.
+	expiresAt := time.Now().Add(s.accessTokenTTL)
+	key := sessionDenylistKey(id)
+	if err := s.revokedTokenRepo.Create(&models.RevokedToken{
+		JTI:       key,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+	}); err != nil {
+		return err
+	}
+	s.denylist.Add(key, expiresAt)
+	s.sessionTouches.forget(id)
+	return nil
+}
+
+// startSession records a new login and returns a token pair bound to it.
+func (s *authService) startSession(user *models.User, client *models.ClientInfo) (*models.AuthResponse, error) {
+	if user.DisabledAt != nil {
+		return nil, ErrAccountDisabled
+	}
+
+	now := time.Now()
+	session := &models.Session{
+		ID:         uuid.New(),
+		UserID:     user.ID,
+		UserAgent:  truncate(client.UserAgent, 512),
+		IP:         client.IP,
+		LastSeenAt: now,
+	}
+	if err := s.sessionRepo.Create(session); err != nil {
+		return nil, err
+	}
+
+	raw, refreshToken, err := s.newRefreshToken(user.ID, session.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.refreshTokenRepo.Create(refreshToken); err != nil {
+		return nil, err
+	}
+	return s.buildAuthResponse(user, session.ID, raw)
+}
+
+func sessionDenylistKey(id uuid.UUID) string {
+	return "session:" + id.String()
+}
+
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max]
+}
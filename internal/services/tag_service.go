@@ -0,0 +1,145 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"mamonedz/internal/models"
+	"mamonedz/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrTagNotFound  = errors.New("tag not found")
+	ErrTagExists    = errors.New("tag already exists")
+	ErrInvalidMerge = errors.New("a tag cannot be merged into itself")
+	ErrEmptyTagName = errors.New("tag name is empty")
+)
+
+type TagService interface {
+	Create(userID uuid.UUID, req *models.CreateTagRequest) (*models.Tag, error)
+	GetAll(userID uuid.UUID) ([]models.TagWithUsage, error)
+	Rename(id, userID uuid.UUID, req *models.RenameTagRequest) (*models.Tag, error)
+	Merge(id, userID uuid.UUID, req *models.MergeTagRequest) (*models.Tag, error)
+	Delete(id, userID uuid.UUID) error
+}
+
+type tagService struct {
+	repo repository.TagRepository
+}
+
+func NewTagService(repo repository.TagRepository) TagService {
+	return &tagService{repo: repo}
+}
+
+// NormalizeTags lowercases and trims tag names and drops empty and duplicate
+// ones, so "Trip-Bali" and "trip-bali " are the same tag.
+func NormalizeTags(names []string) []string {
+	seen := make(map[string]bool, len(names))
+	result := make([]string, 0, len(names))
+	for _, name := range names {
+		name = normalizeTag(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		result = append(result, name)
+	}
+	return result
+}
+
+func (s *tagService) Create(userID uuid.UUID, req *models.CreateTagRequest) (*models.Tag, error) {
+	name := normalizeTag(req.Name)
+	if name == "" {
+		return nil, ErrEmptyTagName
+	}
+
+	tag := &models.Tag{UserID: userID, Name: name}
+	if err := s.repo.Create(tag); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, ErrTagExists
+		}
+		return nil, err
+	}
+	return tag, nil
+}
+
+func (s *tagService) GetAll(userID uuid.UUID) ([]models.TagWithUsage, error) {
+	return s.repo.GetAllByUser(userID)
+}
+
+func (s *tagService) Rename(id, userID uuid.UUID, req *models.RenameTagRequest) (*models.Tag, error) {
+	tag, err := s.get(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	name := normalizeTag(req.Name)
+	if name == "" {
+		return nil, ErrEmptyTagName
+	}
+	if name == tag.Name {
+		return tag, nil
+	}
+
+	if err := s.repo.Rename(id, userID, name); err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return nil, ErrTagNotFound
+		case errors.Is(err, gorm.ErrDuplicatedKey):
+			return nil, ErrTagExists
+		}
+		return nil, err
+	}
+	tag.Name = name
+	return tag, nil
+}
+
+// Merge folds tag id into req.IntoID and returns the surviving tag.
+func (s *tagService) Merge(id, userID uuid.UUID, req *models.MergeTagRequest) (*models.Tag, error) {
+	if id == req.IntoID {
+		return nil, ErrInvalidMerge
+	}
+	if _, err := s.get(id, userID); err != nil {
+		return nil, err
+	}
+	target, err := s.get(req.IntoID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Merge(id, target.ID, userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTagNotFound
+		}
+		return nil, err
+	}
+	return target, nil
+}
+
+func (s *tagService) Delete(id, userID uuid.UUID) error {
+	if err := s.repo.Delete(id, userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrTagNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *tagService) get(id, userID uuid.UUID) (*models.Tag, error) {
+	tag, err := s.repo.GetByID(id, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTagNotFound
+		}
+		return nil, err
+	}
+	return tag, nil
+}
+
+func normalizeTag(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"mamonedz/internal/repository"
+)
+
+// tokenDenylist keeps revoked access token IDs in memory so that checking a
+// token on every request does not cost a database round trip. Revocations
+// made by other instances are picked up by an incremental reload that runs at
+// most once per syncInterval. The reload also purges expired rows from the
+// database, at most once per revokedTokenPurgeInterval.
+type tokenDenylist struct {
+	repo         repository.RevokedTokenRepository
+	syncInterval time.Duration
+
+	mu        sync.RWMutex
+	entries   map[string]time.Time
+	lastSync  time.Time
+	lastPurge time.Time
+}
+
+const revokedTokenPurgeInterval = time.Hour
+
+func newTokenDenylist(repo repository.RevokedTokenRepository, syncInterval time.Duration) *tokenDenylist {
+	return &tokenDenylist{
+		repo:         repo,
+		syncInterval: syncInterval,
+		entries:      make(map[string]time.Time),
+	}
+}
+
+func (d *tokenDenylist) Add(jti string, expiresAt time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.entries[jti] = expiresAt
+}
+
+func (d *tokenDenylist) Contains(jti string) bool {
+	d.sync()
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	_, ok := d.entries[jti]
+	return ok
+}
+
+func (d *tokenDenylist) sync() {
+	d.mu.RLock()
+	due := time.Since(d.lastSync) >= d.syncInterval
+	d.mu.RUnlock()
+	if !due {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if time.Since(d.lastSync) < d.syncInterval {
+		return
+	}
+
+	now := time.Now()
+	// Overlap the window slightly so rows committed while the previous
+	// reload was running are not missed.
+	revoked, err := d.repo.ListSince(d.lastSync.Add(-d.syncInterval))
+	if err != nil {
+		log.Printf("Failed to sync revoked tokens: %v", err)
+		return
+	}
+	for _, t := range revoked {
+		d.entries[t.JTI] = t.ExpiresAt
+	}
+	for jti, exp := range d.entries {
+		if now.After(exp) {
+			delete(d.entries, jti)
+		}
+	}
+	d.lastSync = now
+
+	if now.Sub(d.lastPurge) >= revokedTokenPurgeInterval {
+		if _, err := d.repo.DeleteExpired(now); err != nil {
+			log.Printf("Failed to purge expired revoked tokens: %v", err)
+		}
+		d.lastPurge = now
+	}
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"mamonedz/internal/models"
+	"mamonedz/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// defaultFeeCategory is the expense category transfer fees are filed under
+// when the request does not name one.
+const defaultFeeCategory = "lainnya"
+
+var (
+	ErrTransferNotFound = errors.New("transfer not found")
+	ErrCurrencyMismatch = errors.New("accounts use different currencies")
+)
+
+type TransferService interface {
+	Create(userID uuid.UUID, req *models.CreateTransferRequest) (*models.Transfer, error)
+	GetByID(id, userID uuid.UUID) (*models.Transfer, error)
+	GetAll(filter *models.TransferFilter) ([]models.Transfer, int64, error)
+	Delete(id, userID uuid.UUID) error
+}
+
+type transferService struct {
+	repo         repository.TransferRepository
+	accountRepo  repository.AccountRepository
+	categoryRepo repository.CategoryRepository
+	ledgerRepo   repository.LedgerRepository
+	budgets      BudgetService
+}
+
+func NewTransferService(repo repository.TransferRepository, accountRepo repository.AccountRepository, categoryRepo repository.CategoryRepository, ledgerRepo repository.LedgerRepository, budgets BudgetService) TransferService {
+	return &transferService{repo: repo, accountRepo: accountRepo, categoryRepo: categoryRepo, ledgerRepo: ledgerRepo, budgets: budgets}
+}
+
+func (s *transferService) Create(userID uuid.UUID, req *models.CreateTransferRequest) (*models.Transfer, error) {
+	from, err := s.account(req.FromAccountID, userID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.account(req.ToAccountID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if from.Currency != to.Currency {
+		return nil, ErrCurrencyMismatch
+	}
+
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		return nil, ErrInvalidDate
+	}
+
+	transfer := &models.Transfer{
+		UserID:        userID,
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        req.Amount,
+		Fee:           req.Fee,
+		Date:          date,
+		Note:          req.Note,
+	}
+
+	var fee *models.Expense
+	if req.Fee > 0 {
+		category, err := s.feeCategory(userID, req.FeeCategoryID)
+		if err != nil {
+			return nil, err
+		}
+		ledger, err := s.ledgerRepo.GetPersonal(userID)
+		if err != nil {
+			return nil, err
+		}
+		note := "Transfer fee"
+		fee = &models.Expense{
+			UserID:     userID,
+			LedgerID:   ledger.ID,
+			PaidBy:     userID,
+			Type:       models.TransactionTypeExpense,
+			Amount:     req.Fee,
+			Currency:   from.Currency,
+			CategoryID: category.ID,
+			Category:   category.Name,
+			AccountID:  &from.ID,
+			Date:       date,
+			Note:       &note,
+		}
+	}
+
+	if err := s.repo.Create(transfer, fee); err != nil {
+		return nil, err
+	}
+	if fee != nil {
+		checkBudgets(s.budgets, fee)
+	}
+	return transfer, nil
+}
+
+func (s *transferService) GetByID(id, userID uuid.UUID) (*models.Transfer, error) {
+	transfer, err := s.repo.GetByID(id, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTransferNotFound
+		}
+		return nil, err
+	}
+	return transfer, nil
+}
+
+func (s *transferService) GetAll(filter *models.TransferFilter) ([]models.Transfer, int64, error) {
+	return s.repo.GetAll(filter)
+}
+
+// Delete removes the transfer and the expense recording its fee.
+func (s *transferService) Delete(id, userID uuid.UUID) error {
+	if err := s.repo.Delete(id, userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrTransferNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *transferService) account(id, userID uuid.UUID) (*models.Account, error) {
+	account, err := s.accountRepo.GetByID(id, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidAccount
+		}
+		return nil, err
+	}
+	return account, nil
+}
+
+func (s *transferService) feeCategory(userID uuid.UUID, id *uuid.UUID) (*models.Category, error) {
+	var category *models.Category
+	var err error
+	if id != nil {
+		category, err = s.categoryRepo.GetByID(*id, userID)
+	} else {
+		category, err = s.categoryRepo.GetByName(defaultFeeCategory, models.TransactionTypeExpense, userID)
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidCategory
+		}
+		return nil, err
+	}
+	if category.Type != models.TransactionTypeExpense {
+		return nil, ErrInvalidCategory
+	}
+	return category, nil
+}
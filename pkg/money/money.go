@@ -0,0 +1,209 @@
+// Package money implements an exact amount of money with two fixed minor
+// units, matching the decimal(15,2) columns it is stored in. Amounts never
+// pass through floating point, so sums are always exact and values with more
+// precision than a hundredth are rejected instead of rounded.
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Amount is an amount of money in minor units (hundredths).
+type Amount int64
+
+const (
+	// Scale is the number of minor unit digits.
+	Scale = 2
+	unit  = 100
+	// maxDigits is the number of whole digits decimal(15,2) can hold.
+	maxDigits = 13
+)
+
+// JSON formats. FormatString encodes amounts as decimal strings such as
+// "12500.50"; FormatMinorUnits as integer minor units such as 1250050.
+const (
+	FormatString     = "string"
+	FormatMinorUnits = "minor_units"
+)
+
+var (
+	ErrInvalid     = errors.New("invalid amount")
+	ErrTooPrecise  = errors.New("amount has more than 2 decimal places")
+	ErrOutOfRange  = errors.New("amount out of range")
+	ErrInvalidJSON = errors.New("invalid money JSON format")
+)
+
+var jsonFormat = FormatString
+
+// SetJSONFormat selects how amounts are encoded to and decoded from JSON. It
+// is meant to be called once at startup.
+func SetJSONFormat(format string) error {
+	if format != FormatString && format != FormatMinorUnits {
+		return fmt.Errorf("%w: %s (use %s or %s)", ErrInvalidJSON, format, FormatString, FormatMinorUnits)
+	}
+	jsonFormat = format
+	return nil
+}
+
+// FromMinor returns the amount of the given number of minor units.
+func FromMinor(minor int64) Amount {
+	return Amount(minor)
+}
+
+// Parse reads a decimal amount such as "12500", "-3.5" or "0.25". Trailing
+// zeros beyond the second decimal place are allowed; anything else there is
+// ErrTooPrecise. A point must have digits on both sides.
+func Parse(s string) (Amount, error) {
+	s = strings.TrimSpace(s)
+	negative := false
+	if strings.HasPrefix(s, "-") {
+		negative = true
+		s = s[1:]
+	} else if strings.HasPrefix(s, "+") {
+		s = s[1:]
+	}
+
+	whole, frac, hasPoint := strings.Cut(s, ".")
+	if whole == "" || !isDigits(whole) || !isDigits(frac) || (hasPoint && frac == "") {
+		return 0, ErrInvalid
+	}
+	if len(frac) > Scale {
+		if strings.Trim(frac[Scale:], "0") != "" {
+			return 0, ErrTooPrecise
+		}
+		frac = frac[:Scale]
+	}
+	frac += strings.Repeat("0", Scale-len(frac))
+
+	whole = strings.TrimLeft(whole, "0")
+	if len(whole) > maxDigits {
+		return 0, ErrOutOfRange
+	}
+
+	minor, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return 0, ErrOutOfRange
+	}
+	if negative {
+		minor = -minor
+	}
+	return Amount(minor), nil
+}
+
+// Minor returns the amount in minor units.
+func (a Amount) Minor() int64 {
+	return int64(a)
+}
+
+// Float64 returns the amount in major units, for ratios and percentages only.
+func (a Amount) Float64() float64 {
+	return float64(a) / unit
+}
+
+// String formats the amount with exactly two decimal places.
+func (a Amount) String() string {
+	sign := ""
+	minor := int64(a)
+	if minor < 0 {
+		sign = "-"
+		minor = -minor
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, minor/unit, minor%unit)
+}
+
+func (a Amount) MarshalJSON() ([]byte, error) {
+	if jsonFormat == FormatMinorUnits {
+		return []byte(strconv.FormatInt(int64(a), 10)), nil
+	}
+	return []byte(`"` + a.String() + `"`), nil
+}
+
+// UnmarshalJSON accepts a decimal string in either format. A bare number is
+// read as minor units with FormatMinorUnits, and as a decimal amount
+// otherwise, so older clients sending plain numbers keep working.
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+
+	if strings.HasPrefix(s, `"`) {
+		unquoted, err := strconv.Unquote(s)
+		if err != nil {
+			return ErrInvalid
+		}
+		parsed, err := Parse(unquoted)
+		if err != nil {
+			return err
+		}
+		*a = parsed
+		return nil
+	}
+
+	if jsonFormat == FormatMinorUnits {
+		minor, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return ErrInvalid
+		}
+		if minor >= int64(math.Pow10(maxDigits+Scale)) || minor <= -int64(math.Pow10(maxDigits+Scale)) {
+			return ErrOutOfRange
+		}
+		*a = Amount(minor)
+		return nil
+	}
+
+	parsed, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// Scan reads a numeric column, which Postgres returns as text.
+func (a *Amount) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*a = 0
+		return nil
+	case []byte:
+		return a.scanString(string(v))
+	case string:
+		return a.scanString(v)
+	case int64:
+		*a = Amount(v * unit)
+		return nil
+	case float64:
+		*a = Amount(math.Round(v * unit))
+		return nil
+	default:
+		return fmt.Errorf("money: cannot scan %T", src)
+	}
+}
+
+func (a *Amount) scanString(s string) error {
+	parsed, err := Parse(s)
+	if err != nil {
+		return fmt.Errorf("money: cannot scan %q: %w", s, err)
+	}
+	*a = parsed
+	return nil
+}
+
+func (a Amount) Value() (driver.Value, error) {
+	return a.String(), nil
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
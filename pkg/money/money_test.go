@@ -0,0 +1,204 @@
+package money
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Amount
+		err  error
+	}{
+		{"12500", 1250000, nil},
+		{"12500.50", 1250050, nil},
+		{"-3.5", -350, nil},
+		{"+3.5", 350, nil},
+		{"0.25", 25, nil},
+		{" 7 ", 700, nil},
+		{"007.10", 710, nil},
+		{"1.500", 150, nil},
+		{"-0", 0, nil},
+		{"9999999999999.99", 999999999999999, nil},
+		{"0.001", 0, ErrTooPrecise},
+		{"1.005", 0, ErrTooPrecise},
+		{"1.", 0, ErrInvalid},
+		{".5", 0, ErrInvalid},
+		{"-", 0, ErrInvalid},
+		{"", 0, ErrInvalid},
+		{"1.2.3", 0, ErrInvalid},
+		{"1e3", 0, ErrInvalid},
+		{"--1", 0, ErrInvalid},
+		{"12,50", 0, ErrInvalid},
+		{"10000000000000", 0, ErrOutOfRange},
+		{"-10000000000000.00", 0, ErrOutOfRange},
+		{"00000000000001", 100, nil},
+	}
+
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("Parse(%q) error = %v, want %v", tt.in, err, tt.err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Parse(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		in   Amount
+		want string
+	}{
+		{0, "0.00"},
+		{5, "0.05"},
+		{-5, "-0.05"},
+		{1250050, "12500.50"},
+		{-350, "-3.50"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.in.String(); got != tt.want {
+			t.Errorf("Amount(%d).String() = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+// withJSONFormat switches the package-wide JSON format for one test.
+func withJSONFormat(t *testing.T, format string) {
+	t.Helper()
+	previous := jsonFormat
+	if err := SetJSONFormat(format); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { jsonFormat = previous })
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		format string
+		in     string
+		want   Amount
+		err    error
+	}{
+		{FormatString, `"12500.50"`, 1250050, nil},
+		{FormatString, `12500.5`, 1250050, nil},
+		{FormatString, `12500`, 1250000, nil},
+		{FormatString, `"0.001"`, 0, ErrTooPrecise},
+		{FormatString, `0.001`, 0, ErrTooPrecise},
+		{FormatString, `"abc"`, 0, ErrInvalid},
+		{FormatString, `"12`, 0, ErrInvalid},
+		{FormatMinorUnits, `1250050`, 1250050, nil},
+		{FormatMinorUnits, `-350`, -350, nil},
+		{FormatMinorUnits, `"12500.50"`, 1250050, nil},
+		{FormatMinorUnits, `12500.5`, 0, ErrInvalid},
+		{FormatMinorUnits, `999999999999999`, 999999999999999, nil},
+		{FormatMinorUnits, `1000000000000000`, 0, ErrOutOfRange},
+		{FormatMinorUnits, `-1000000000000000`, 0, ErrOutOfRange},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format+" "+tt.in, func(t *testing.T) {
+			withJSONFormat(t, tt.format)
+
+			var got Amount
+			err := got.UnmarshalJSON([]byte(tt.in))
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("error = %v, want %v", err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnmarshalJSONNullKeepsValue(t *testing.T) {
+	a := Amount(42)
+	if err := a.UnmarshalJSON([]byte("null")); err != nil {
+		t.Fatal(err)
+	}
+	if a != 42 {
+		t.Errorf("null changed the amount to %d", a)
+	}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{FormatString, `"-12500.50"`},
+		{FormatMinorUnits, `-1250050`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			withJSONFormat(t, tt.format)
+
+			got, err := Amount(-1250050).MarshalJSON()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetJSONFormatRejectsUnknown(t *testing.T) {
+	if err := SetJSONFormat("float"); !errors.Is(err, ErrInvalidJSON) {
+		t.Errorf("error = %v, want %v", err, ErrInvalidJSON)
+	}
+}
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     interface{}
+		want    Amount
+		wantErr bool
+	}{
+		{"nil", nil, 0, false},
+		{"bytes", []byte("12500.50"), 1250050, false},
+		{"string", "-3.50", -350, false},
+		{"int64", int64(12), 1200, false},
+		{"float64", 0.29, 29, false},
+		{"too precise", "1.005", 0, true},
+		{"garbage", []byte("abc"), 0, true},
+		{"unsupported type", true, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := Amount(-1)
+			err := a.Scan(tt.src)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && a != tt.want {
+				t.Errorf("got %d, want %d", a, tt.want)
+			}
+		})
+	}
+}
+
+func TestValueRoundTrip(t *testing.T) {
+	for _, a := range []Amount{0, 1, -1, 1250050, -999999999999999} {
+		v, err := a.Value()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got Amount
+		if err := got.Scan(v); err != nil {
+			t.Fatal(err)
+		}
+		if got != a {
+			t.Errorf("round trip of %d gave %d", a, got)
+		}
+	}
+}
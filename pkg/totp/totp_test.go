@@ -0,0 +1,99 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA1 seed from RFC 6238 appendix B ("12345678901234567890")
+// in base32.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCodeRFC6238Vectors(t *testing.T) {
+	// The RFC lists 8-digit codes; with 6 digits they keep the last six.
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+		got, err := Code(rfcSecret, Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("Code at %d: %v", tt.unix, err)
+		}
+		if got != tt.want {
+			t.Errorf("Code at %d = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestCodeNormalizesSecret(t *testing.T) {
+	got, err := Code(" gezdgnbvgy3tqojqgezdgnbvgy3tqojq ", Step(time.Unix(59, 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "287082" {
+		t.Errorf("Code = %s, want 287082", got)
+	}
+}
+
+func TestCodeInvalidSecret(t *testing.T) {
+	if _, err := Code("not base32!", 1); err == nil {
+		t.Error("Code accepted an invalid secret")
+	}
+}
+
+func TestValidateSkew(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := Step(now)
+
+	tests := []struct {
+		name   string
+		offset int64
+		skew   int
+		ok     bool
+	}{
+		{"current step", 0, 0, true},
+		{"previous step without skew", -1, 0, false},
+		{"previous step", -1, 1, true},
+		{"next step", 1, 1, true},
+		{"two steps behind", -2, 1, false},
+		{"two steps ahead", 2, 1, false},
+		{"two steps behind with wider skew", -2, 2, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := Code(rfcSecret, current+tt.offset)
+			if err != nil {
+				t.Fatal(err)
+			}
+			step, ok := Validate(rfcSecret, code, now, tt.skew)
+			if ok != tt.ok {
+				t.Fatalf("Validate ok = %v, want %v", ok, tt.ok)
+			}
+			if ok && step != current+tt.offset {
+				t.Errorf("Validate step = %d, want %d", step, current+tt.offset)
+			}
+		})
+	}
+}
+
+func TestValidateRejectsMalformedCodes(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	for _, code := range []string{"", "05047", "0504710", "abcdef"} {
+		if _, ok := Validate(rfcSecret, code, now, 1); ok {
+			t.Errorf("Validate accepted %q", code)
+		}
+	}
+	if _, ok := Validate(rfcSecret, " 050471 ", now, 0); !ok {
+		t.Error("Validate rejected a code with surrounding spaces")
+	}
+}